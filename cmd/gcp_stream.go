@@ -0,0 +1,205 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimbertools/loggo/loggo"
+	"github.com/jimbertools/loggo/reader"
+	"github.com/spf13/cobra"
+)
+
+const fromLayout = "2006-01-02T15:04:05"
+
+var relativeFrom = regexp.MustCompile(`^(\d+)([smhd])$`)
+
+type gcpStreamOptions struct {
+	project      string
+	filter       string
+	from         string
+	templateFile string
+	gcloudAuth   bool
+	forceAuth    bool
+	paramsSave   string
+	paramsLoad   string
+	paramsList   bool
+	fromTime     time.Time
+}
+
+var gcpOpts = gcpStreamOptions{}
+
+var gcpStreamCmd = &cobra.Command{
+	Use:   "gcp-stream",
+	Short: "Continuously stream GCP stack driver logs",
+	Long: `Continuously stream Google Cloud Platform log entries
+from a given selected project and GCP logging filters:
+
+	loggo gcp-stream --project myGCPProject123 --from 1m \
+		--filter 'resource.labels.namespace_name="awesome-sit" AND resource.labels.container_name="some"'`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if gcpOpts.paramsList {
+			return nil
+		}
+		if len(gcpOpts.paramsLoad) > 0 {
+			if err := gcpOpts.load(cmd); err != nil {
+				return err
+			}
+		}
+		return gcpOpts.validate(time.Now())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if gcpOpts.paramsList {
+			return listParams()
+		}
+		if len(gcpOpts.paramsSave) > 0 {
+			if err := reader.Save(gcpOpts.paramsSave, gcpOpts.savedParams()); err != nil {
+				return err
+			}
+		}
+		tokenSource := reader.NativeTokenSource()
+		if gcpOpts.gcloudAuth {
+			tokenSource = reader.GCloudTokenSource(gcpOpts.forceAuth)
+		}
+		gcpReader := reader.MakeGCPReader(gcpOpts.project, gcpOpts.filter, gcpOpts.fromTime, tokenSource)
+		app, err := loggo.NewLoggoApp(gcpReader, gcpOpts.templateFile)
+		if err != nil {
+			return err
+		}
+		return app.Run()
+	},
+}
+
+func init() {
+	flags := gcpStreamCmd.Flags()
+	flags.StringVarP(&gcpOpts.project, "project", "p", "", "GCP Project ID (required)")
+	flags.StringVarP(&gcpOpts.filter, "filter", "f", "", "Standard GCP filters")
+	flags.StringVarP(&gcpOpts.from, "from", "d", "tail",
+		`Start streaming from:
+  Relative: Use format "1s", "1m", "1h" or "1d", where:
+            digit followed by s, m, h, d as second, minute, hour, day.
+  Fixed:    Use date format as "yyyy-MM-ddH24:mm:ss", e.g. 2022-07-30T15:00:00
+  Now:      Use "tail" to start from now`)
+	flags.StringVarP(&gcpOpts.templateFile, "template", "t", "", "Rendering Template")
+	flags.BoolVar(&gcpOpts.gcloudAuth, "gcloud-auth", false,
+		`Use the existing GCloud CLI infrastructure installed on your system for GCP
+authentication. You must have gcloud CLI installed and configured. If this
+flag is not passed, it uses l'oggo native connector.`)
+	flags.BoolVar(&gcpOpts.forceAuth, "force-auth", false,
+		`Only effective if combined with gcloud flag. Force re-authentication even
+if you may have a valid authentication file.`)
+	flags.StringVar(&gcpOpts.paramsSave, "params-save", "",
+		`Save the following parameters (if provided) for reuse:
+  Project:   The GCP Project ID
+  Template:  The rendering template to be applied.
+  From:      When to start streaming from.
+  Filter:    The GCP specific filter parameters.`)
+	flags.StringVar(&gcpOpts.paramsLoad, "params-load", "",
+		`Load the parameters for reuse. If any additional parameters are
+provided, it overrides the loaded parameter with the one explicitly provided.`)
+	flags.BoolVar(&gcpOpts.paramsList, "params-list", false,
+		"List saved gcp connection/filtering parameters for convenient reuse.")
+}
+
+// load fills in every parameter that wasn't explicitly provided on the command
+// line with its saved counterpart.
+func (o *gcpStreamOptions) load(cmd *cobra.Command) error {
+	sp, err := reader.Load(o.paramsLoad)
+	if err != nil {
+		return fmt.Errorf("unable to load params %s: %w", o.paramsLoad, err)
+	}
+	flags := cmd.Flags()
+	if !flags.Changed("project") {
+		o.project = sp.Project
+	}
+	if !flags.Changed("filter") {
+		o.filter = sp.Filter
+	}
+	if !flags.Changed("from") && len(sp.From) > 0 {
+		o.from = sp.From
+	}
+	if !flags.Changed("template") {
+		o.templateFile = sp.Template
+	}
+	return nil
+}
+
+func (o *gcpStreamOptions) validate(now time.Time) error {
+	if len(strings.TrimSpace(o.project)) == 0 {
+		return fmt.Errorf("--project is required")
+	}
+	var err error
+	if o.fromTime, err = parseFrom(o.from, now); err != nil {
+		return err
+	}
+	return validateTemplate(o.templateFile)
+}
+
+func (o *gcpStreamOptions) savedParams() *reader.SavedParams {
+	return &reader.SavedParams{
+		From:     o.from,
+		Filter:   o.filter,
+		Project:  o.project,
+		Template: o.templateFile,
+	}
+}
+
+func listParams() error {
+	list, err := reader.List()
+	if err != nil {
+		return err
+	}
+	for _, sp := range list {
+		sp.Print()
+	}
+	return nil
+}
+
+// parseFrom resolves the --from flag into the instant streaming starts from.
+func parseFrom(from string, now time.Time) (time.Time, error) {
+	from = strings.TrimSpace(from)
+	if len(from) == 0 || from == "tail" {
+		return now, nil
+	}
+	if m := relativeFrom.FindStringSubmatch(from); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{
+			"s": time.Second,
+			"m": time.Minute,
+			"h": time.Hour,
+			"d": 24 * time.Hour,
+		}[m[2]]
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+	t, err := time.ParseInLocation(fromLayout, from, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(`invalid --from %q: use "tail", a relative value such as "10m" `+
+			`or a date such as "2022-07-30T15:00:00"`, from)
+	}
+	return t, nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFrom(t *testing.T) {
+	now := time.Date(2022, 7, 30, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		givenFrom string
+		wants     time.Time
+		wantsErr  bool
+	}{
+		{name: "Tail", givenFrom: "tail", wants: now},
+		{name: "Empty", givenFrom: "", wants: now},
+		{name: "Seconds", givenFrom: "30s", wants: now.Add(-30 * time.Second)},
+		{name: "Minutes", givenFrom: "10m", wants: now.Add(-10 * time.Minute)},
+		{name: "Hours", givenFrom: "2h", wants: now.Add(-2 * time.Hour)},
+		{name: "Days", givenFrom: "1d", wants: now.Add(-24 * time.Hour)},
+		{name: "Fixed", givenFrom: "2022-07-29T10:30:00", wants: time.Date(2022, 7, 29, 10, 30, 0, 0, time.Local)},
+		{name: "Bad unit", givenFrom: "10w", wantsErr: true},
+		{name: "Bad date", givenFrom: "2022/07/29", wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseFrom(test.givenFrom, now)
+			if test.wantsErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, test.wants.Equal(got), "wants %v, got %v", test.wants, got)
+			}
+		})
+	}
+}
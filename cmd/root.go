@@ -0,0 +1,63 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "loggo",
+	Short: "l'oGGo: Rich Terminal User Interface for streaming structured logs",
+	Long: `l'oGGo or Log & Go is a rich Terminal User Interface app that harness the
+power of your terminal to digest log streams based on JSON based logs.
+
+Logs can be streamed from files, piped input or GCP Logging, and rendered
+according to a template that can be crafted with the template editor.`,
+	SilenceUsage: true,
+}
+
+// Execute runs the loggo command tree, exiting with a non-zero code on failure.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(streamCmd, templateCmd, gcpStreamCmd)
+}
+
+// validateTemplate ensures an explicitly provided template file can be read.
+func validateTemplate(templateFile string) error {
+	if len(templateFile) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(templateFile); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jimbertools/loggo/config"
+	"github.com/jimbertools/loggo/loggo"
+	"github.com/jimbertools/loggo/parser"
+	"github.com/jimbertools/loggo/reader"
+	"github.com/spf13/cobra"
+)
+
+type streamOptions struct {
+	files        []string
+	watch        []string
+	syslog       string
+	exec         string
+	templateFile string
+	offset       int64
+	noFollow     bool
+	checkpoint   bool
+	resume       bool
+	journald     bool
+	parser       string
+	multiline    string
+	prettyJSON   bool
+	preset       string
+	redact       []string
+	allowReveal  bool
+	timezone     string
+}
+
+var streamOpts = streamOptions{}
+
+var streamCmd = &cobra.Command{
+	Use:   "stream",
+	Short: "Continuously stream log input source",
+	Long: `Continuously stream logs from one or more files or from the piped input, e.g.:
+
+	loggo stream --file <my file>
+	loggo stream --file <my file> --file <my other file>
+	loggo stream --watch '/var/log/app/*.json'
+	loggo stream --syslog udp://:5514
+	loggo stream --exec "kubectl logs -f deploy/api"
+	loggo stream --file <my file> --parser logfmt
+	loggo stream --file <my file> --preset pino
+	loggo stream --file <my file> --redact email,card,jwt
+	loggo stream --file <my file> --timezone UTC
+	loggo stream --file <my file> --multiline '^\d{4}-\d{2}-\d{2}'
+	journalctl -o json -f | loggo stream --journald
+	tail -f <my file> | loggo stream --template <my template yaml>`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return streamOpts.validate()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var viewerOpts []loggo.ViewerOption
+		if streamOpts.noFollow {
+			viewerOpts = append(viewerOpts, loggo.WithNoFollow())
+		}
+		if streamOpts.checkpoint {
+			viewerOpts = append(viewerOpts, loggo.WithCheckpoints())
+		}
+		if len(streamOpts.parser) > 0 {
+			// Validated beforehand
+			c, _ := parser.ParseSpec(streamOpts.parser)
+			viewerOpts = append(viewerOpts, loggo.WithParser(c))
+		}
+		if len(streamOpts.multiline) > 0 {
+			viewerOpts = append(viewerOpts, loggo.WithMultiline(&config.MultilineConfig{
+				Start: streamOpts.multiline,
+			}))
+		}
+		if streamOpts.prettyJSON {
+			viewerOpts = append(viewerOpts, loggo.WithMultiline(&config.MultilineConfig{
+				JSON: true,
+			}))
+		}
+		if len(streamOpts.preset) > 0 {
+			viewerOpts = append(viewerOpts, loggo.WithPreset(streamOpts.preset))
+		}
+		if r := streamOpts.redaction(); r != nil {
+			viewerOpts = append(viewerOpts, loggo.WithRedaction(r))
+		}
+		if len(streamOpts.timezone) > 0 {
+			viewerOpts = append(viewerOpts, loggo.WithTimezone(streamOpts.timezone))
+		}
+		if streamOpts.journald {
+			fileName := ""
+			if len(streamOpts.files) == 1 {
+				fileName = streamOpts.files[0]
+			}
+			return loggo.StartJournaldLogViewer(fileName, streamOpts.templateFile, viewerOpts...)
+		}
+		if len(streamOpts.exec) > 0 {
+			return loggo.StartExecLogViewer(streamOpts.exec, streamOpts.templateFile, viewerOpts...)
+		}
+		if len(streamOpts.syslog) > 0 {
+			return loggo.StartSyslogLogViewer(streamOpts.syslog, streamOpts.templateFile, viewerOpts...)
+		}
+		if len(streamOpts.watch) > 0 {
+			return loggo.StartWatchLogViewer(streamOpts.watch, streamOpts.templateFile, viewerOpts...)
+		}
+		if streamOpts.resume || (streamOpts.offset == 0 && isTerminal(os.Stdin) &&
+			offerResume(streamOpts.files, os.Stdin, os.Stdout)) {
+			viewerOpts = append(viewerOpts, loggo.WithResume())
+		}
+		if len(streamOpts.files) > 1 {
+			return loggo.StartMultiFileLogViewer(streamOpts.files, streamOpts.templateFile, viewerOpts...)
+		}
+		fileName := ""
+		if len(streamOpts.files) == 1 {
+			fileName = streamOpts.files[0]
+		}
+		return loggo.StartLogViewer(fileName, append(viewerOpts,
+			loggo.WithTemplate(streamOpts.templateFile),
+			loggo.WithOffset(streamOpts.offset))...)
+	},
+}
+
+func init() {
+	streamCmd.Flags().StringSliceVarP(&streamOpts.files, "file", "f", nil,
+		"Input log file(s). Repeat the flag or separate by comma to stream multiple files.\n"+
+			"If omitted, logs are read from the piped input.")
+	streamCmd.Flags().StringSliceVarP(&streamOpts.watch, "watch", "w", nil,
+		"Glob pattern(s) of log files to stream, e.g. '/var/log/app/*.json'. Files matching\n"+
+			"the pattern are picked up as they're created and dropped once removed.")
+	streamCmd.Flags().StringVar(&streamOpts.syslog, "syslog", "",
+		"Listen for RFC 5424/3164 syslog messages at the given address, e.g. udp://:5514,\n"+
+			"tcp://0.0.0.0:5514, unix:///tmp/loggo.sock or unixgram:///tmp/loggo.sock.")
+	streamCmd.Flags().StringVar(&streamOpts.exec, "exec", "",
+		"Run the given command and stream its stdout and stderr, restarting it with a\n"+
+			"backoff whenever it exits. With --no-follow, it runs only once.")
+	streamCmd.Flags().BoolVar(&streamOpts.journald, "journald", false,
+		"Read journalctl output in json or export format (-o json, -o export) from the\n"+
+			"piped input or a single --file, laid out with the built-in journald template.")
+	streamCmd.Flags().StringVar(&streamOpts.parser, "parser", "",
+		"Parser of the lines, overriding the one of the template: json (default),\n"+
+			"lenient-json[:<prefix pattern>] (JSON objects after a prefix), logfmt,\n"+
+			"combined (Apache/Nginx access logs), csv (with a header row), regex:<pattern>,\n"+
+			"the pattern's named groups becoming fields, e.g. 'regex:(?P<level>\\w+) (?P<msg>.*)',\n"+
+			"or grok:<pattern>, e.g. 'grok:%{TIMESTAMP_ISO8601:ts} %{LOGLEVEL:level} %{GREEDYDATA:msg}'.")
+	streamCmd.Flags().StringVar(&streamOpts.multiline, "multiline", "",
+		"Regular expression matching the first line of each record, joining the lines that\n"+
+			"don't match to the record before them, e.g. stack traces. Overrides the template.")
+	streamCmd.Flags().BoolVar(&streamOpts.prettyJSON, "pretty-json", false,
+		"Assemble JSON objects pretty-printed over several lines, each malformed chunk\n"+
+			"showing as a single parse error. Overrides the template.")
+	streamCmd.Flags().StringVar(&streamOpts.preset, "preset", "",
+		"Built-in template to lay the logs out with, instead of the one picked by looking at\n"+
+			"the first records: "+strings.Join(presetNames(), ", ")+",\n"+
+			"or none to derive the layout from the logs.")
+	streamCmd.Flags().StringSliceVar(&streamOpts.redact, "redact", nil,
+		"Mask the values found by the given detectors before they're shown, on top of the\n"+
+			"redaction rules of the template and ~/.loggo/redact.yaml: "+strings.Join(config.Detectors(), ", ")+".")
+	streamCmd.Flags().BoolVar(&streamOpts.allowReveal, "allow-reveal", false,
+		"Keep the raw values of redacted records, for them to be revealed with ^r.")
+	streamCmd.Flags().StringVar(&streamOpts.timezone, "timezone", "",
+		"Show times in the given timezone: local, UTC or an IANA name such as Europe/Brussels.\n"+
+			"Times are shown in the one they were logged in by default, see ^z to switch.")
+	streamCmd.Flags().StringVarP(&streamOpts.templateFile, "template", "t", "",
+		"Rendering Template")
+	streamCmd.Flags().BoolVar(&streamOpts.noFollow, "no-follow", false,
+		"Read the input to the end and stop, instead of following it for new lines.")
+	streamCmd.Flags().BoolVar(&streamOpts.checkpoint, "checkpoint", false,
+		"Save where the file(s) were left off as loggo quits, for a later session to resume.")
+	streamCmd.Flags().BoolVar(&streamOpts.resume, "resume", false,
+		"Resume the file(s) from where the last session left off, even if rotated since.\n"+
+			"Without it, you're asked whether to resume when there's a past session.")
+	streamCmd.Flags().Int64Var(&streamOpts.offset, "offset", 0,
+		"Byte offset to start reading the file from. Only valid with a single file.")
+}
+
+func (o *streamOptions) validate() error {
+	for _, f := range o.files {
+		if info, err := os.Stat(f); err != nil {
+			return fmt.Errorf("invalid file: %w", err)
+		} else if info.IsDir() {
+			return fmt.Errorf("invalid file: %s is a directory", f)
+		}
+	}
+	for _, p := range o.watch {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid watch pattern %s: %w", p, err)
+		}
+	}
+	if len(o.parser) > 0 {
+		if _, err := parser.ParseSpec(o.parser); err != nil {
+			return err
+		}
+		if o.journald || len(o.syslog) > 0 {
+			return fmt.Errorf("--parser can't be combined with --journald or --syslog")
+		}
+	}
+	if len(o.multiline) > 0 {
+		if _, err := regexp.Compile(o.multiline); err != nil {
+			return fmt.Errorf("invalid multiline pattern: %w", err)
+		}
+		if o.journald || len(o.syslog) > 0 {
+			return fmt.Errorf("--multiline can't be combined with --journald or --syslog")
+		}
+	}
+	if o.prettyJSON {
+		if len(o.multiline) > 0 {
+			return fmt.Errorf("--pretty-json can't be combined with --multiline")
+		}
+		if o.journald || len(o.syslog) > 0 {
+			return fmt.Errorf("--pretty-json can't be combined with --journald or --syslog")
+		}
+	}
+	if len(o.preset) > 0 {
+		if o.preset != config.NoPreset {
+			if _, err := config.MakeBuiltinConfig(o.preset); err != nil {
+				return fmt.Errorf("invalid preset: %w", err)
+			}
+		}
+		if len(o.templateFile) > 0 || o.journald || len(o.syslog) > 0 {
+			return fmt.Errorf("--preset can't be combined with --template, --journald or --syslog")
+		}
+	}
+	if _, err := config.LoadTimezone(o.timezone); err != nil {
+		return err
+	}
+	if r := o.redaction(); r != nil {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.journald {
+		if len(o.files) > 1 || len(o.watch) > 0 || len(o.syslog) > 0 || len(o.exec) > 0 ||
+			o.noFollow || o.checkpoint || o.resume || o.offset != 0 {
+			return fmt.Errorf("--journald can only be combined with a single --file and --template")
+		}
+	}
+	if len(o.exec) > 0 {
+		if len(o.files) > 0 || len(o.watch) > 0 || len(o.syslog) > 0 || o.resume || o.offset != 0 {
+			return fmt.Errorf("--exec can only be combined with --no-follow and --template")
+		}
+	}
+	if len(o.syslog) > 0 {
+		if _, _, err := reader.SplitSyslogAddress(o.syslog); err != nil {
+			return err
+		}
+		if len(o.files) > 0 || len(o.watch) > 0 || o.noFollow || o.resume || o.offset != 0 {
+			return fmt.Errorf("--syslog can't be combined with file options")
+		}
+	}
+	if len(o.watch) > 0 && len(o.files) > 0 {
+		return fmt.Errorf("--watch can't be combined with --file")
+	}
+	if len(o.watch) > 0 && o.noFollow {
+		return fmt.Errorf("--watch can't be combined with --no-follow")
+	}
+	if o.offset < 0 {
+		return fmt.Errorf("invalid offset %d: must not be negative", o.offset)
+	}
+	if o.checkpoint && len(o.files) == 0 && len(o.watch) == 0 {
+		return fmt.Errorf("--checkpoint requires --file or --watch")
+	}
+	if o.resume && len(o.files) == 0 {
+		return fmt.Errorf("--resume requires at least one --file")
+	}
+	if o.resume && o.offset > 0 {
+		return fmt.Errorf("--resume can't be combined with --offset")
+	}
+	if o.offset > 0 && len(o.files) != 1 {
+		return fmt.Errorf("--offset requires exactly one --file")
+	}
+	return validateTemplate(o.templateFile)
+}
+
+// redaction builds the redaction rules given by the flags, if any.
+func (o *streamOptions) redaction() *config.Redaction {
+	if len(o.redact) == 0 && !o.allowReveal {
+		return nil
+	}
+	r := &config.Redaction{AllowReveal: o.allowReveal}
+	for _, d := range o.redact {
+		r.Rules = append(r.Rules, config.RedactionRule{Name: d, Detector: d})
+	}
+	return r
+}
+
+func presetNames() []string {
+	var names []string
+	for _, p := range config.Presets() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// offerResume asks whether to resume, if any of the files was read in a past session.
+func offerResume(files []string, in io.Reader, out io.Writer) bool {
+	var last *reader.Checkpoint
+	for _, f := range files {
+		cp, err := reader.LoadCheckpoint(f)
+		if err == nil && cp != nil && (last == nil || cp.Time.After(last.Time)) {
+			last = cp
+		}
+	}
+	if last == nil {
+		return false
+	}
+	_, _ = fmt.Fprintf(out, "Resume where you left off at %s? [y/N] ",
+		last.Time.Format("2006-01-02 15:04:05"))
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
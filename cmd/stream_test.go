@@ -0,0 +1,130 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimbertools/loggo/reader"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    streamOptions
+		wantsErr bool
+	}{
+		{name: "Pipe", given: streamOptions{}},
+		{name: "Single file", given: streamOptions{files: []string{"../testdata/test1.json"}}},
+		{name: "Offset with single file", given: streamOptions{files: []string{"../testdata/test1.json"}, offset: 10}},
+		{name: "Missing file", given: streamOptions{files: []string{"foo"}}, wantsErr: true},
+		{name: "Directory", given: streamOptions{files: []string{"../testdata"}}, wantsErr: true},
+		{name: "Negative offset", given: streamOptions{files: []string{"../testdata/test1.json"}, offset: -1}, wantsErr: true},
+		{name: "Offset on pipe", given: streamOptions{offset: 10}, wantsErr: true},
+		{name: "Watch", given: streamOptions{watch: []string{"../testdata/*.json"}}},
+		{name: "Bad watch pattern", given: streamOptions{watch: []string{"../testdata/[.json"}}, wantsErr: true},
+		{name: "Watch with file", given: streamOptions{watch: []string{"*.json"}, files: []string{"../testdata/test1.json"}}, wantsErr: true},
+		{name: "No follow", given: streamOptions{files: []string{"../testdata/test1.json"}, noFollow: true}},
+		{name: "Watch without follow", given: streamOptions{watch: []string{"*.json"}, noFollow: true}, wantsErr: true},
+		{name: "Resume", given: streamOptions{files: []string{"../testdata/test1.json"}, resume: true}},
+		{name: "Resume on pipe", given: streamOptions{resume: true}, wantsErr: true},
+		{name: "Checkpoint", given: streamOptions{files: []string{"../testdata/test1.json"}, checkpoint: true}},
+		{name: "Checkpoint with watch", given: streamOptions{watch: []string{"../testdata/*.json"}, checkpoint: true}},
+		{name: "Checkpoint on pipe", given: streamOptions{checkpoint: true}, wantsErr: true},
+		{name: "Journald with checkpoint", given: streamOptions{journald: true, files: []string{"../testdata/test1.json"}, checkpoint: true}, wantsErr: true},
+		{name: "Resume with offset", given: streamOptions{files: []string{"../testdata/test1.json"}, resume: true, offset: 10}, wantsErr: true},
+		{name: "Syslog", given: streamOptions{syslog: "udp://:5514"}},
+		{name: "Bad syslog network", given: streamOptions{syslog: "http://:5514"}, wantsErr: true},
+		{name: "Syslog with file", given: streamOptions{syslog: "tcp://:5514", files: []string{"../testdata/test1.json"}}, wantsErr: true},
+		{name: "Exec", given: streamOptions{exec: "kubectl logs -f deploy/api", noFollow: true}},
+		{name: "Exec with syslog", given: streamOptions{exec: "kubectl logs -f deploy/api", syslog: "udp://:5514"}, wantsErr: true},
+		{name: "Journald", given: streamOptions{journald: true}},
+		{name: "Journald with file", given: streamOptions{journald: true, files: []string{"../testdata/test1.json"}}},
+		{name: "Journald with exec", given: streamOptions{journald: true, exec: "journalctl -o json -f"}, wantsErr: true},
+		{name: "Journald with resume", given: streamOptions{journald: true, files: []string{"../testdata/test1.json"}, resume: true}, wantsErr: true},
+		{name: "Parser", given: streamOptions{parser: "logfmt"}},
+		{name: "Regex parser", given: streamOptions{parser: `regex:(?P<level>\w+) (?P<msg>.*)`}},
+		{name: "Grok parser", given: streamOptions{parser: `grok:%{LOGLEVEL:level} %{GREEDYDATA:msg}`}},
+		{name: "Unknown grok pattern", given: streamOptions{parser: `grok:%{FOO:level}`}, wantsErr: true},
+		{name: "Multiline", given: streamOptions{multiline: `^\d{4}-`}},
+		{name: "Bad multiline pattern", given: streamOptions{multiline: `^(`}, wantsErr: true},
+		{name: "Multiline with syslog", given: streamOptions{multiline: `^\d{4}-`, syslog: "udp://:5514"}, wantsErr: true},
+		{name: "Pretty JSON", given: streamOptions{prettyJSON: true}},
+		{name: "Pretty JSON with multiline", given: streamOptions{prettyJSON: true, multiline: `^\d{4}-`}, wantsErr: true},
+		{name: "Lenient JSON parser", given: streamOptions{parser: `lenient-json:^(?P<level>\w+)`}},
+		{name: "Unknown parser", given: streamOptions{parser: "xml"}, wantsErr: true},
+		{name: "Parser with journald", given: streamOptions{parser: "logfmt", journald: true}, wantsErr: true},
+		{name: "Preset", given: streamOptions{preset: "pino"}},
+		{name: "No preset", given: streamOptions{preset: "none"}},
+		{name: "Unknown preset", given: streamOptions{preset: "log4j"}, wantsErr: true},
+		{name: "Preset with template", given: streamOptions{preset: "pino", templateFile: "../config-sample/logfmt.yaml"}, wantsErr: true},
+		{name: "Redact", given: streamOptions{redact: []string{"email", "card"}, allowReveal: true}},
+		{name: "Redact with journald", given: streamOptions{redact: []string{"jwt"}, journald: true}},
+		{name: "Unknown detector", given: streamOptions{redact: []string{"ssn"}}, wantsErr: true},
+		{name: "Timezone", given: streamOptions{timezone: "Europe/Brussels"}},
+		{name: "Unknown timezone", given: streamOptions{timezone: "Mars/Olympus_Mons"}, wantsErr: true},
+		{name: "Missing template", given: streamOptions{templateFile: "foo"}, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.given.validate()
+			if test.wantsErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOfferResume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	assert.NoError(t, reader.SaveCheckpoint(&reader.Checkpoint{
+		Path:   "../testdata/test1.json",
+		Offset: 10,
+		Time:   time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+	}))
+
+	tests := []struct {
+		name       string
+		givenFiles []string
+		givenInput string
+		wants      bool
+		wantsAsked bool
+	}{
+		{name: "Accepted", givenFiles: []string{"../testdata/test1.json"}, givenInput: "y\n", wants: true, wantsAsked: true},
+		{name: "Declined", givenFiles: []string{"../testdata/test1.json"}, givenInput: "\n", wantsAsked: true},
+		{name: "No past session", givenFiles: []string{"../testdata/test3.txt"}, givenInput: "y\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Equal(t, test.wants, offerResume(test.givenFiles, strings.NewReader(test.givenInput), &out))
+			assert.Equal(t, test.wantsAsked, strings.Contains(out.String(), "2022-10-01 12:00:00"))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/jimbertools/loggo/config"
+	"github.com/jimbertools/loggo/loggo"
+	"github.com/spf13/cobra"
+)
+
+var templateFile string
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Starts the loggo template manager",
+	Long: `Opens the template editor without the need to stream logs, so templates can
+be crafted prior using the stream commands, e.g.:
+
+	loggo template
+	loggo template --file <my template yaml>`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateTemplate(templateFile)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.MakeConfig(templateFile)
+		if err != nil {
+			return err
+		}
+		app := loggo.NewAppWithConfig(cfg)
+		view := loggo.NewTemplateView(app, true, nil, nil)
+		app.Run(view)
+		return nil
+	},
+}
+
+func init() {
+	templateCmd.Flags().StringVarP(&templateFile, "file", "f", "",
+		"Template file to edit. If omitted, a blank canvas is opened.")
+}
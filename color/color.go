@@ -22,7 +22,11 @@ THE SOFTWARE.
 
 package color
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"hash/fnv"
+
+	"github.com/gdamore/tcell/v2"
+)
 
 const (
 	ColorBackgroundField    = tcell.ColorBlack
@@ -43,9 +47,56 @@ var (
 			Foreground(ColorSelectedForeground)
 )
 
+// Theme is the palette used to render loggo's fields and selections.
+type Theme struct {
+	Background         tcell.Color
+	Foreground         tcell.Color
+	SelectedBackground tcell.Color
+	SelectedForeground tcell.Color
+}
+
+// DefaultTheme is the palette loggo renders with unless an app is given another one.
+var DefaultTheme = Theme{
+	Background:         ColorBackgroundField,
+	Foreground:         ColorForegroundField,
+	SelectedBackground: ColorSelectedBackground,
+	SelectedForeground: ColorSelectedForeground,
+}
+
+// FieldStyle is the style of fields in the theme.
+func (t Theme) FieldStyle() tcell.Style {
+	return tcell.StyleDefault.Background(t.Background).Foreground(t.Foreground)
+}
+
+// SelectStyle is the style of selected items in the theme.
+func (t Theme) SelectStyle() tcell.Style {
+	return tcell.StyleDefault.Background(t.SelectedBackground).Foreground(t.SelectedForeground)
+}
+
 const (
 	ClField   = "[#ffaf00::b]"
 	ClWhite   = "[#ffffff::-]"
 	ClNumeric = "[#00afff]"
 	ClString  = "[#6A9F59]"
 )
+
+// SourcePalette is the set of colours used to tell log sources apart.
+var SourcePalette = []tcell.Color{
+	tcell.ColorAqua,
+	tcell.ColorFuchsia,
+	tcell.ColorLime,
+	tcell.ColorYellow,
+	tcell.ColorOrange,
+	tcell.ColorDeepSkyBlue,
+	tcell.ColorViolet,
+	tcell.ColorSpringGreen,
+	tcell.ColorSalmon,
+	tcell.ColorKhaki,
+}
+
+// ForValue consistently picks a colour from SourcePalette for the given value.
+func ForValue(value string) tcell.Color {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(value))
+	return SourcePalette[h.Sum32()%uint32(len(SourcePalette))]
+}
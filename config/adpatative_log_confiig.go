@@ -24,7 +24,6 @@ package config
 
 import (
 	"sort"
-	"strings"
 )
 
 func MakeConfigFromSample(sample []map[string]interface{}, mergeWith ...Key) (*Config, map[string]*Key) {
@@ -35,23 +34,36 @@ func MakeConfigFromSample(sample []map[string]interface{}, mergeWith ...Key) (*C
 			keyMap[v.Name] = &v
 		}
 	}
+	multiSource := countSources(sample) > 1
 	for _, m := range sample {
 		for _, k := range extractKeys2ndDepth(m) {
 			if _, ok := keyMap[k]; ok {
 				continue
 			}
-			if k == ParseErr {
+			if k == ParseErr || k == Continuation || k == Level || k == Redacted {
 				continue
 			}
-			if timestamp.Contains(k) {
+			if source.Contains(k) {
+				// A single source adds nothing but noise
+				if multiSource {
+					keyMap[k] = source.keyConfig(k)
+				}
+				continue
+			} else if timestamp.Contains(k) {
 				keyMap[k] = timestamp.keyConfig(k)
 				continue
 			} else if logType.Contains(k) {
 				keyMap[k] = logType.keyConfig(k)
 				continue
+			} else if origin.Contains(k) {
+				keyMap[k] = origin.keyConfig(k)
+				continue
 			} else if traceId.Contains(k) {
 				keyMap[k] = traceId.keyConfig(k)
 				continue
+			} else if spanId.Contains(k) {
+				keyMap[k] = spanId.keyConfig(k)
+				continue
 			} else if message.Contains(k) {
 				keyMap[k] = message.keyConfig(k)
 				continue
@@ -78,20 +90,27 @@ func MakeConfigFromSample(sample []map[string]interface{}, mergeWith ...Key) (*C
 		Keys: []Key{},
 	}
 	var orderedKeys []string
+	orderedKeys = append(orderedKeys, source.Keys()...)
 	orderedKeys = append(orderedKeys, timestamp.Keys()...)
 	orderedKeys = append(orderedKeys, logType.Keys()...)
+	orderedKeys = append(orderedKeys, origin.Keys()...)
 	orderedKeys = append(orderedKeys, traceId.Keys()...)
+	orderedKeys = append(orderedKeys, spanId.Keys()...)
 	orderedKeys = append(orderedKeys, message.Keys()...)
 	orderedKeys = append(orderedKeys, errorKey.Keys()...)
+	added := make(map[string]bool)
 	for _, v := range orderedKeys {
-		if v, ok := keyMap[v]; ok {
+		// User aliases may repeat a key in several rules.
+		if v, ok := keyMap[v]; ok && !added[v.Name] {
+			added[v.Name] = true
 			c.Keys = append(c.Keys, *v)
 		}
 	}
 
 	var sk []string
 	for k := range keyMap {
-		if !timestamp.Contains(k) && !message.Contains(k) && !traceId.Contains(k) && !logType.Contains(k) && !errorKey.Contains(k) {
+		if !source.Contains(k) && !timestamp.Contains(k) && !message.Contains(k) && !traceId.Contains(k) && !spanId.Contains(k) && !logType.Contains(k) && !errorKey.Contains(k) &&
+			!origin.Contains(k) {
 			sk = append(sk, k)
 		}
 	}
@@ -105,42 +124,87 @@ func MakeConfigFromSample(sample []map[string]interface{}, mergeWith ...Key) (*C
 type preBakedRule struct {
 	keyMatchesAny map[string]bool
 	keyConfig     func(keyName string) *Key
+	// order lists the keys in display order. If empty, keys are sorted by name.
+	order []string
+	// role makes the rule match the aliases of the role too, in their order.
+	role Role
 }
 
 func (p preBakedRule) Contains(key string) bool {
 	if _, ok := p.keyMatchesAny[key]; ok {
 		return ok
 	}
+	if p.role != RoleNone {
+		for _, alias := range p.aliases() {
+			if alias == key {
+				return true
+			}
+		}
+	}
 	return false
 }
 
 func (p preBakedRule) Keys() []string {
+	if len(p.order) > 0 {
+		return p.order
+	}
 	var arr []string
 	for k := range p.keyMatchesAny {
 		arr = append(arr, k)
 	}
 	sort.Strings(arr)
+	if p.role != RoleNone {
+		return append(p.aliases(), arr...)
+	}
 	return arr
 }
 
+// aliases are the key names of the rule's role, without a template's own ones.
+func (p preBakedRule) aliases() []string {
+	return (*Config)(nil).RoleAliases()[p.role]
+}
+
+func countSources(sample []map[string]interface{}) int {
+	sources := make(map[interface{}]bool)
+	for _, m := range sample {
+		if v, ok := m[Source]; ok {
+			sources[v] = true
+		}
+	}
+	return len(sources)
+}
+
 func extractKeys2ndDepth(m map[string]interface{}) []string {
 	keys := make([]string, 0)
 	for k, _ := range m {
-		if strings.Contains(k, "/") {
-			continue
-		}
-		// Only include top-level keys, don't process nested objects
+		// Only include top-level keys, don't process nested objects. Keys with slashes
+		// are found by their whole name, see Key.ExtractValues.
 		keys = append(keys, k)
 	}
 	return keys
 }
 
 var (
+	source = preBakedRule{
+		keyMatchesAny: map[string]bool{Source: true},
+		keyConfig: func(keyName string) *Key {
+			// No foreground, so that each source gets its own colour
+			return &Key{
+				Name:     keyName,
+				Type:     TypeString,
+				MaxWidth: 20,
+				Color: Color{
+					Background: "black",
+				},
+			}
+		},
+	}
 	timestamp = preBakedRule{
-		keyMatchesAny: map[string]bool{"timestamp": true, "time": true},
+		role: RoleTimestamp,
 		keyConfig: func(keyName string) *Key {
 			return &Key{
 				Name: keyName,
+				Role: RoleTimestamp,
 				Type: TypeDateTime,
 				Color: Color{
 					Foreground: "purple",
@@ -149,12 +213,37 @@ var (
 			}
 		},
 	}
+	// origin covers where a record was emitted from, as told by syslog or a container
+	// runtime.
+	origin = preBakedRule{
+		keyMatchesAny: map[string]bool{
+			"facility": true,
+			"hostname": true,
+			"appName":  true,
+			"procId":   true,
+			"msgId":    true,
+			"$_stream": true,
+		},
+		order: []string{"hostname", "appName", "procId", "msgId", "facility", "$_stream"},
+		keyConfig: func(keyName string) *Key {
+			return &Key{
+				Name:     keyName,
+				Type:     TypeString,
+				MaxWidth: 16,
+				Color: Color{
+					Foreground: "teal",
+					Background: "black",
+				},
+			}
+		},
+	}
 	traceId = preBakedRule{
-		keyMatchesAny: map[string]bool{"traceId": true},
+		role: RoleTrace,
 		keyConfig: func(keyName string) *Key {
 			return &Key{
 				Name:     keyName,
-				Type:     TypeDateTime,
+				Role:     RoleTrace,
+				Type:     TypeString,
 				MaxWidth: 32,
 				Color: Color{
 					Foreground: "olive",
@@ -163,11 +252,27 @@ var (
 			}
 		},
 	}
+	spanId = preBakedRule{
+		role: RoleSpan,
+		keyConfig: func(keyName string) *Key {
+			return &Key{
+				Name:     keyName,
+				Role:     RoleSpan,
+				Type:     TypeString,
+				MaxWidth: 16,
+				Color: Color{
+					Foreground: "olive",
+					Background: "black",
+				},
+			}
+		},
+	}
 	logType = preBakedRule{
-		keyMatchesAny: map[string]bool{"level": true, "severity": true},
+		role: RoleLevel,
 		keyConfig: func(keyName string) *Key {
 			return &Key{
 				Name: keyName,
+				Role: RoleLevel,
 				Type: TypeString,
 				Color: Color{
 					Foreground: "white",
@@ -175,7 +280,7 @@ var (
 				},
 				ColorWhen: []ColorWhen{
 					{
-						MatchValue: "(?i)error",
+						MatchValue: "(?i)error|critical|alert|emergency|fatal|panic",
 						Color: Color{
 							Foreground: "red",
 							Background: "black",
@@ -208,13 +313,17 @@ var (
 	}
 	message = preBakedRule{
 		keyMatchesAny: map[string]bool{
-			"message":             true,
-			"jsonPayload/message": true,
-			"http_request":        true,
+			"http_request": true,
 		},
+		role: RoleMessage,
 		keyConfig: func(keyName string) *Key {
+			role := RoleMessage
+			if keyName == "http_request" {
+				role = RoleNone
+			}
 			return &Key{
 				Name:     keyName,
+				Role:     role,
 				Type:     TypeString,
 				MaxWidth: 60,
 				Color: Color{
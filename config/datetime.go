@@ -0,0 +1,166 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultDisplayLayout is how times are shown once converted to another timezone,
+	// for keys with neither a display layout nor a layout.
+	DefaultDisplayLayout = "2006-01-02T15:04:05.000Z07:00"
+
+	TimezoneLocal = "local"
+	TimezoneUTC   = "utc"
+)
+
+// fallbackLayouts are tried on times matching none of the layouts of their key, nor
+// being epochs.
+var fallbackLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	time.DateTime,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.DateOnly,
+}
+
+var epochRegex = regexp.MustCompile(`^-?\d{1,19}(?:\.\d+)?$`)
+
+// ParseTime parses value as a time, trying the layouts of the key in turn, then epoch
+// numbers in seconds, milliseconds, microseconds or nanoseconds, as told apart by their
+// magnitude, and lastly RFC 3339 and other common layouts. Times without a timezone
+// are taken as UTC.
+func (k *Key) ParseTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range k.layouts() {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	if t, ok := parseEpoch(value); ok {
+		return t, true
+	}
+	for _, layout := range fallbackLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// layouts returns Layout followed by Layouts.
+func (k *Key) layouts() []string {
+	if len(k.Layout) == 0 {
+		return k.Layouts
+	}
+	return append([]string{k.Layout}, k.Layouts...)
+}
+
+// parseEpoch parses value as a number of seconds, milliseconds, microseconds or
+// nanoseconds since the epoch, whichever puts it closer to now, along with fractions
+// of them.
+func parseEpoch(value string) (time.Time, bool) {
+	if !epochRegex.MatchString(value) {
+		return time.Time{}, false
+	}
+	whole, frac, _ := strings.Cut(value, ".")
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// The fraction in nanoseconds, were the unit a second.
+	frac = (frac + "000000000")[:9]
+	fn, _ := strconv.ParseInt(frac, 10, 64)
+	if strings.HasPrefix(whole, "-") {
+		fn = -fn
+	}
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	var t time.Time
+	switch {
+	case abs < 1e11:
+		t = time.Unix(n, fn)
+	case abs < 1e14:
+		t = time.UnixMilli(n).Add(time.Duration(fn / 1e3))
+	case abs < 1e17:
+		t = time.UnixMicro(n).Add(time.Duration(fn / 1e6))
+	default:
+		t = time.Unix(0, n)
+	}
+	return t.UTC(), true
+}
+
+// FormatTime shows value, a time of the key, in its display layout and in loc, unless
+// loc is nil, in which case the timezone it was logged in is kept, epochs being in UTC.
+// Values are shown as they are if the key has no display layout and loc is nil, or if
+// they can't be parsed, see ParseTime.
+func (k *Key) FormatTime(value string, loc *time.Location) string {
+	if k.Type != TypeDateTime || (loc == nil && len(k.DisplayLayout) == 0) {
+		return value
+	}
+	t, ok := k.ParseTime(value)
+	if !ok {
+		return value
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	layout := k.DisplayLayout
+	if len(layout) == 0 {
+		layout = k.Layout
+	}
+	if len(layout) == 0 {
+		layout = DefaultDisplayLayout
+	}
+	return t.Format(layout)
+}
+
+// LoadTimezone loads the timezone called name, either local, UTC or an IANA name such as
+// Europe/Brussels, whatever the case of the first two. It returns nil if name is empty,
+// for times to be shown in the timezone they were logged in.
+func LoadTimezone(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return nil, nil
+	case TimezoneLocal:
+		return time.Local, nil
+	case TimezoneUTC:
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %s", name)
+	}
+	return loc, nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey_ParseTime(t *testing.T) {
+	noon := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	k := &Key{Name: "ts", Type: TypeDateTime, Layout: "02/01/2006 15:04:05", Layouts: []string{"Jan 2 2006 15:04"}}
+	tests := []struct {
+		name     string
+		given    string
+		expected time.Time
+		wantsErr bool
+	}{
+		{name: "Layout", given: "01/10/2026 12:00:00", expected: noon},
+		{name: "Second layout", given: "Oct 1 2026 12:00", expected: noon},
+		{name: "Epoch seconds", given: "1790856000", expected: noon},
+		{name: "Epoch seconds with fraction", given: "1790856000.25", expected: noon.Add(250 * time.Millisecond)},
+		{name: "Epoch milliseconds", given: "1790856000250", expected: noon.Add(250 * time.Millisecond)},
+		{name: "Epoch milliseconds with fraction", given: "1790856000250.5", expected: noon.Add(250*time.Millisecond + 500*time.Microsecond)},
+		{name: "Epoch microseconds", given: "1790856000000250", expected: noon.Add(250 * time.Microsecond)},
+		{name: "Epoch nanoseconds", given: "1790856000000000250", expected: noon.Add(250)},
+		{name: "Before the epoch", given: "-86400.5", expected: time.Date(1969, 12, 30, 23, 59, 59, 5e8, time.UTC)},
+		{name: "RFC 3339", given: "2026-10-01T14:00:00+02:00", expected: noon},
+		{name: "RFC 3339 with nanoseconds", given: "2026-10-01T12:00:00.000000250Z", expected: noon.Add(250)},
+		{name: "Without timezone", given: "2026-10-01 12:00:00", expected: noon},
+		{name: "Date", given: "2026-10-01", expected: noon.Add(-12 * time.Hour)},
+		{name: "Not a time", given: "yesterday", wantsErr: true},
+		{name: "Out of range", given: "17908560000000000000", wantsErr: true},
+		{name: "Empty", given: "", wantsErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := k.ParseTime(tt.given)
+			assert.Equal(t, !tt.wantsErr, ok)
+			if ok {
+				assert.True(t, tt.expected.Equal(got), "%s != %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestKey_FormatTime(t *testing.T) {
+	brussels, err := LoadTimezone("Europe/Brussels")
+	assert.NoError(t, err)
+	tests := []struct {
+		name     string
+		key      Key
+		given    string
+		loc      *time.Location
+		expected string
+	}{
+		{
+			name:     "As logged",
+			key:      Key{Type: TypeDateTime, Layout: time.RFC3339},
+			given:    "2026-10-01T14:00:00+02:00",
+			expected: "2026-10-01T14:00:00+02:00",
+		},
+		{
+			name:     "Display layout",
+			key:      Key{Type: TypeDateTime, DisplayLayout: time.Kitchen},
+			given:    "2026-10-01T14:00:00+02:00",
+			expected: "2:00PM",
+		},
+		{
+			name:     "UTC in layout",
+			key:      Key{Type: TypeDateTime, Layout: time.RFC3339},
+			given:    "2026-10-01T14:00:00+02:00",
+			loc:      time.UTC,
+			expected: "2026-10-01T12:00:00Z",
+		},
+		{
+			name:     "Epoch in named timezone",
+			key:      Key{Type: TypeDateTime},
+			given:    "1790856000250",
+			loc:      brussels,
+			expected: "2026-10-01T14:00:00.250+02:00",
+		},
+		{
+			name:     "Epoch in display layout",
+			key:      Key{Type: TypeDateTime, DisplayLayout: DefaultDisplayLayout},
+			given:    "1790856000.5",
+			expected: "2026-10-01T12:00:00.500Z",
+		},
+		{
+			name:     "Not a time",
+			key:      Key{Type: TypeDateTime},
+			given:    "n/a",
+			loc:      time.UTC,
+			expected: "n/a",
+		},
+		{
+			name:     "Not a datetime key",
+			key:      Key{Type: TypeNumber},
+			given:    "1790856000",
+			loc:      time.UTC,
+			expected: "1790856000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.key.FormatTime(tt.given, tt.loc))
+		})
+	}
+}
+
+func TestLoadTimezone(t *testing.T) {
+	tests := []struct {
+		given    string
+		expected string
+		wantsErr bool
+	}{
+		{given: "", expected: ""},
+		{given: "Local", expected: "Local"},
+		{given: "UTC", expected: "UTC"},
+		{given: "utc", expected: "UTC"},
+		{given: "America/New_York", expected: "America/New_York"},
+		{given: "Mars/Olympus_Mons", wantsErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.given, func(t *testing.T) {
+			loc, err := LoadTimezone(tt.given)
+			assert.Equal(t, tt.wantsErr, err != nil)
+			if len(tt.expected) == 0 {
+				assert.Nil(t, loc)
+			} else {
+				assert.Equal(t, tt.expected, loc.String())
+			}
+		})
+	}
+}
+
+func TestMakeConfig_Timezone(t *testing.T) {
+	file := t.TempDir() + "/template.yaml"
+	assert.NoError(t, os.WriteFile(file, []byte(`timezone: Europe/Brussels
+keys:
+  - name: ts
+    type: datetime
+    layout: 2006-01-02 15:04:05
+    layouts: [Jan 2 15:04:05]
+    display-layout: "15:04:05.000"
+`), 0644))
+	c, err := MakeConfig(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Brussels", c.Timezone)
+	assert.Equal(t, []string{"Jan 2 15:04:05"}, c.Keys[0].Layouts)
+	assert.Equal(t, "15:04:05.000", c.Keys[0].DisplayLayout)
+
+	assert.NoError(t, os.WriteFile(file, []byte("timezone: Mars/Olympus_Mons\n"), 0644))
+	_, err = MakeConfig(file)
+	assert.Error(t, err)
+}
@@ -0,0 +1,419 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Derive computes the value of a key out of other fields of the record, rather than
+// reading it from the record, either with an arithmetic Expression or by extracting it
+// with Regex from the field From.
+type Derive struct {
+	// Expression is an arithmetic expression with +, -, *, / and %, over numbers and
+	// fields referred to by path, e.g. (end - start) / 1000. Fields holding RFC 3339
+	// times stand for milliseconds since the epoch, so that the difference of two is
+	// in milliseconds. Dashes in paths are escaped as \-, see Path.
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+	// From is the path of the field Regex is applied to.
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+	// Regex extracts the value from the field From: its first capture group that's
+	// matched, or the whole match if it has no group, e.g. ^\w+://([^/:?#]+) for the host
+	// of a URL.
+	Regex string `json:"regex,omitempty" yaml:"regex,omitempty"`
+	// compiled is the parsed derivation, kept once it's first needed.
+	compiled atomic.Pointer[compiledDerive]
+}
+
+// compiledDerive is a parsed Derive, err being set if it's invalid.
+type compiledDerive struct {
+	// source is what the derivation was parsed from, telling whether it was edited since.
+	source [3]string
+	expr   exprNode
+	from   *Key
+	re     *regexp.Regexp
+	err    error
+}
+
+func (d *Derive) source() [3]string {
+	return [3]string{d.Expression, d.From, d.Regex}
+}
+
+// compile returns the parsed derivation, parsing it the first time and again whenever
+// it was edited since.
+func (d *Derive) compile() *compiledDerive {
+	if c := d.compiled.Load(); c != nil && c.source == d.source() {
+		return c
+	}
+	c := d.parse()
+	d.compiled.Store(c)
+	return c
+}
+
+func (d *Derive) parse() *compiledDerive {
+	c := &compiledDerive{source: d.source()}
+	switch {
+	case len(d.Expression) > 0 && len(d.Regex) > 0:
+		c.err = errors.New("either an expression or a regex is expected, not both")
+	case len(d.Expression) > 0:
+		c.expr, c.err = parseExpression(d.Expression)
+	case len(d.Regex) > 0 && len(d.From) == 0:
+		c.err = errors.New("the field the regex applies to is missing")
+	case len(d.Regex) > 0:
+		if _, err := ParsePath(d.From); err != nil {
+			c.err = err
+		} else if c.re, err = regexp.Compile(d.Regex); err != nil {
+			c.err = fmt.Errorf("invalid regex: %w", err)
+		}
+		c.from = &Key{Name: d.From}
+	default:
+		c.err = errors.New("an expression or a regex is expected")
+	}
+	return c
+}
+
+// Validate checks the expression or the regex of the derivation, keeping it parsed for
+// Value.
+func (d *Derive) Validate() error {
+	return d.compile().err
+}
+
+// Value computes the value of the derivation over m. It returns false if it can't be,
+// for the fields it's computed from are missing for instance, or if it's invalid.
+func (d *Derive) Value(m map[string]interface{}) (string, bool) {
+	c := d.compile()
+	switch {
+	case c.err != nil:
+		return "", false
+	case c.expr != nil:
+		r, ok := c.expr.eval(m)
+		if !ok {
+			return "", false
+		}
+		return formatRat(r), true
+	}
+	match := c.re.FindStringSubmatch(c.from.ExtractValue(m))
+	if match == nil {
+		return "", false
+	}
+	if len(match) == 1 {
+		return match[0], true
+	}
+	for _, group := range match[1:] {
+		if len(group) > 0 {
+			return group, true
+		}
+	}
+	return "", false
+}
+
+// String describes the derivation, as the expression or the regex over its field.
+func (d *Derive) String() string {
+	if len(d.Expression) > 0 {
+		return d.Expression
+	}
+	return fmt.Sprintf("%s ~ %s", d.From, d.Regex)
+}
+
+// WithDerived returns m along with the values of the derived keys, for them to show as
+// fields of the record. Numbers are added as such to keys of type number. m is left
+// untouched, and returned as is if there's no derived value.
+func (c *Config) WithDerived(m map[string]interface{}) map[string]interface{} {
+	var out map[string]interface{}
+	for i := range c.Keys {
+		k := &c.Keys[i]
+		if k.Derive == nil {
+			continue
+		}
+		v, ok := k.Derive.Value(m)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(m)
+		}
+		if k.Type == TypeNumber && isJSONNumber(v) {
+			out[k.Name] = json.Number(v)
+		} else {
+			out[k.Name] = v
+		}
+	}
+	if out == nil {
+		return m
+	}
+	return out
+}
+
+func isJSONNumber(v string) bool {
+	return len(v) > 0 && (v[0] == '-' || (v[0] >= '0' && v[0] <= '9')) && json.Valid([]byte(v))
+}
+
+// formatRat formats r exactly if it's an integer, or else with up to six decimals.
+func formatRat(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := strings.TrimRight(r.FloatString(6), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// exprNode is a node of a parsed expression, evaluated exactly as a rational number.
+type exprNode interface {
+	// eval returns the value of the node over m, or false if it has none.
+	eval(m map[string]interface{}) (*big.Rat, bool)
+}
+
+type numberNode struct {
+	value *big.Rat
+}
+
+func (n *numberNode) eval(map[string]interface{}) (*big.Rat, bool) {
+	return n.value, true
+}
+
+type fieldNode struct {
+	key *Key
+}
+
+func (n *fieldNode) eval(m map[string]interface{}) (*big.Rat, bool) {
+	v := strings.TrimSpace(n.key.ExtractValue(m))
+	if len(v) == 0 {
+		return nil, false
+	}
+	if r, ok := new(big.Rat).SetString(v); ok {
+		return r, true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return new(big.Rat).SetFrac(big.NewInt(t.UnixNano()), big.NewInt(int64(time.Millisecond))), true
+	}
+	return nil, false
+}
+
+type negNode struct {
+	x exprNode
+}
+
+func (n *negNode) eval(m map[string]interface{}) (*big.Rat, bool) {
+	x, ok := n.x.eval(m)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).Neg(x), true
+}
+
+type binaryNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n *binaryNode) eval(m map[string]interface{}) (*big.Rat, bool) {
+	l, ok := n.l.eval(m)
+	if !ok {
+		return nil, false
+	}
+	r, ok := n.r.eval(m)
+	if !ok {
+		return nil, false
+	}
+	switch n.op {
+	case '+':
+		return new(big.Rat).Add(l, r), true
+	case '-':
+		return new(big.Rat).Sub(l, r), true
+	case '*':
+		return new(big.Rat).Mul(l, r), true
+	case '/':
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		return new(big.Rat).Quo(l, r), true
+	case '%':
+		if !l.IsInt() || !r.IsInt() || r.Sign() == 0 {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(new(big.Int).Rem(l.Num(), r.Num())), true
+	}
+	return nil, false
+}
+
+// exprParser parses expressions by recursive descent, * / and % taking precedence
+// over + and -.
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func parseExpression(s string) (exprNode, error) {
+	p := &exprParser{s: s}
+	n, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.s) {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+func (p *exprParser) sum() (exprNode, error) {
+	return p.binary("+-", p.product)
+}
+
+func (p *exprParser) product() (exprNode, error) {
+	return p.binary("*/%", p.unary)
+}
+
+func (p *exprParser) binary(ops string, operand func() (exprNode, error)) (exprNode, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.s) || !strings.ContainsRune(ops, rune(p.s[p.pos])) {
+			return l, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("invalid expression %s: unexpected end", p.s)
+	}
+	switch c := p.s[p.pos]; {
+	case c == '-':
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &negNode{x: x}, nil
+	case c == '(':
+		p.pos++
+		n, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpaces(); p.pos >= len(p.s) || p.s[p.pos] != ')' {
+			return nil, fmt.Errorf("invalid expression %s: missing )", p.s)
+		}
+		p.pos++
+		return n, nil
+	case isDigit(c) || c == '.':
+		return p.number()
+	case isFieldStart(c):
+		return p.field()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *exprParser) number() (exprNode, error) {
+	start := p.pos
+	for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+		p.pos++
+	}
+	if p.pos < len(p.s) && (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.s) && (p.s[p.pos] == '+' || p.s[p.pos] == '-') {
+			p.pos++
+		}
+		for p.pos < len(p.s) && isDigit(p.s[p.pos]) {
+			p.pos++
+		}
+	}
+	r, ok := new(big.Rat).SetString(p.s[start:p.pos])
+	if !ok {
+		return nil, fmt.Errorf("invalid expression %s: bad number %s", p.s, p.s[start:p.pos])
+	}
+	return &numberNode{value: r}, nil
+}
+
+// field parses the path of a field. Unlike in filters, paths can't have wildcards,
+// nor dashes unless escaped, which stand for the operators. Negative indices are fine,
+// e.g. items/-1, as a step can't be empty.
+func (p *exprParser) field() (exprNode, error) {
+	start := p.pos
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; {
+		case c == '\\':
+			p.pos = min(p.pos+2, len(p.s))
+		case c == '"':
+			p.pos++
+			for p.pos < len(p.s) && p.s[p.pos] != '"' {
+				if p.s[p.pos] == '\\' {
+					p.pos++
+				}
+				p.pos++
+			}
+			p.pos = min(p.pos+1, len(p.s))
+		case isFieldStart(c) || isDigit(c) || c == '.' || c == '/':
+			p.pos++
+		case c == '-' && p.s[p.pos-1] == '/':
+			p.pos++
+		default:
+			return p.fieldNode(p.s[start:p.pos])
+		}
+	}
+	return p.fieldNode(p.s[start:])
+}
+
+func (p *exprParser) fieldNode(path string) (exprNode, error) {
+	if _, err := ParsePath(path); err != nil {
+		return nil, err
+	}
+	return &fieldNode{key: &Key{Name: path}}, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) unexpected() error {
+	return fmt.Errorf("invalid expression %s: unexpected %q at %d", p.s, p.s[p.pos], p.pos)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isFieldStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$' || c == '@' || c == '"'
+}
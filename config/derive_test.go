@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerive_Value(t *testing.T) {
+	record := map[string]interface{}{
+		"start":   json.Number("1759320000123"),
+		"end":     json.Number("1759320000456"),
+		"startNs": json.Number("1759320000123456789"),
+		"endNs":   json.Number("1759320000124456789"),
+		"bytes":   json.Number("1536"),
+		"ratio":   "0.25",
+		"begin":   "2026-10-01T12:00:00Z",
+		"finish":  "2026-10-01T12:00:01.5Z",
+		"url":     "https://api.example.com:8443/v1/items?id=1",
+		"message": "request took 42ms for user=bob",
+		"http":    map[string]interface{}{"status-code": json.Number("503")},
+		"labels":  map[string]interface{}{"k8s.io/replicas": json.Number("3")},
+		"items":   []interface{}{json.Number("2"), json.Number("5")},
+		"name":    "api",
+	}
+	tests := []struct {
+		name      string
+		given     *Derive
+		wants     string
+		wantsNone bool
+	}{
+		{name: "Difference", given: &Derive{Expression: "end-start"}, wants: "333"},
+		{name: "Precedence", given: &Derive{Expression: "end - start * 2 + start"}, wants: "333"},
+		{name: "Parentheses", given: &Derive{Expression: "(endNs - startNs) / 1000000"}, wants: "1"},
+		{name: "Exact division", given: &Derive{Expression: "bytes / 1024"}, wants: "1.5"},
+		{name: "Rounded division", given: &Derive{Expression: "1 / 3"}, wants: "0.333333"},
+		{name: "Decimals", given: &Derive{Expression: "ratio * 100"}, wants: "25"},
+		{name: "Exponent", given: &Derive{Expression: "bytes / 1e3"}, wants: "1.536"},
+		{name: "Negation", given: &Derive{Expression: "-(start - end)"}, wants: "333"},
+		{name: "Modulo", given: &Derive{Expression: "bytes % 1000"}, wants: "536"},
+		{name: "Modulo of decimals", given: &Derive{Expression: "ratio % 2"}, wantsNone: true},
+		{name: "Times", given: &Derive{Expression: "finish - begin"}, wants: "1500"},
+		{name: "Nested path", given: &Derive{Expression: `http/status\-code / 100`}, wants: "5.03"},
+		{name: "Quoted path", given: &Derive{Expression: `labels/"k8s.io/replicas" * 2`}, wants: "6"},
+		{name: "Index", given: &Derive{Expression: "items/-1 - items/0"}, wants: "3"},
+		{name: "Missing field", given: &Derive{Expression: "end - missing"}, wantsNone: true},
+		{name: "Not a number", given: &Derive{Expression: "name + 1"}, wantsNone: true},
+		{name: "Division by zero", given: &Derive{Expression: "end / (start - start)"}, wantsNone: true},
+		{name: "Regex group", given: &Derive{From: "url", Regex: `^\w+://([^/:?#]+)`}, wants: "api.example.com"},
+		{name: "Regex match", given: &Derive{From: "message", Regex: `\d+ms`}, wants: "42ms"},
+		{name: "Regex alternatives", given: &Derive{From: "message", Regex: `user=(alice)|user=(\w+)`}, wants: "bob"},
+		{name: "Regex no match", given: &Derive{From: "message", Regex: `status=(\d+)`}, wantsNone: true},
+		{name: "Regex missing field", given: &Derive{From: "missing", Regex: `(.+)`}, wantsNone: true},
+		{name: "Invalid", given: &Derive{Expression: "end -"}, wantsNone: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, ok := test.given.Value(record)
+			assert.Equal(t, !test.wantsNone, ok)
+			assert.Equal(t, test.wants, v)
+		})
+	}
+}
+
+func TestDerive_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    *Derive
+		wantsErr bool
+	}{
+		{name: "Expression", given: &Derive{Expression: "(a/b + c) * 2"}},
+		{name: "Regex", given: &Derive{From: "url", Regex: `//([^/]+)`}},
+		{name: "Empty", given: &Derive{}, wantsErr: true},
+		{name: "Both", given: &Derive{Expression: "a", From: "b", Regex: "c"}, wantsErr: true},
+		{name: "Regex without field", given: &Derive{Regex: `(\d+)`}, wantsErr: true},
+		{name: "Bad regex", given: &Derive{From: "a", Regex: `(`}, wantsErr: true},
+		{name: "Bad field path", given: &Derive{From: `a/"b`, Regex: `.`}, wantsErr: true},
+		{name: "Trailing operator", given: &Derive{Expression: "a +"}, wantsErr: true},
+		{name: "Unbalanced parenthesis", given: &Derive{Expression: "(a + b"}, wantsErr: true},
+		{name: "Unexpected character", given: &Derive{Expression: "a ^ b"}, wantsErr: true},
+		{name: "Bad number", given: &Derive{Expression: "1.2.3"}, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.given.Validate()
+			if test.wantsErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestKey_ExtractValue_Derived(t *testing.T) {
+	m := map[string]interface{}{"start": json.Number("10"), "end": json.Number("25")}
+	k := &Key{Name: "latency", Derive: &Derive{Expression: "end - start"}}
+	assert.Equal(t, "15", k.ExtractValue(m))
+	assert.Equal(t, []string{"15"}, k.ExtractValues(m))
+
+	k = &Key{Name: "end", Derive: &Derive{Expression: "missing"}}
+	assert.Equal(t, "", k.ExtractValue(m))
+	assert.Empty(t, k.ExtractValues(m))
+}
+
+func TestDerive_Edited(t *testing.T) {
+	m := map[string]interface{}{"start": json.Number("10"), "end": json.Number("25")}
+	d := &Derive{Expression: "end - start"}
+	assert.NoError(t, d.Validate())
+	v, _ := d.Value(m)
+	assert.Equal(t, "15", v)
+
+	d.Expression = "end + start"
+	v, _ = d.Value(m)
+	assert.Equal(t, "35", v)
+	assert.NoError(t, d.Validate())
+	assert.Equal(t, d.source(), d.compiled.Load().source)
+}
+
+func TestDerive_CompiledOnce(t *testing.T) {
+	m := map[string]interface{}{"start": json.Number("10"), "end": json.Number("25")}
+	d := &Derive{Expression: "end - start"}
+	d.Value(m)
+	compiled := d.compiled.Load()
+	assert.NotNil(t, compiled)
+	d.Value(m)
+	assert.Same(t, compiled, d.compiled.Load())
+}
+
+func TestConfig_WithDerived(t *testing.T) {
+	c := &Config{Keys: []Key{
+		{Name: "start", Type: TypeNumber},
+		{Name: "latency", Type: TypeNumber, Derive: &Derive{Expression: "end - start"}},
+		{Name: "service", Type: TypeString, Derive: &Derive{From: "url", Regex: `//([^/]+)`}},
+		{Name: "missing", Type: TypeString, Derive: &Derive{From: "nope", Regex: `.+`}},
+	}}
+	m := map[string]interface{}{"start": json.Number("10"), "end": json.Number("25"), "url": "http://api/x"}
+	got := c.WithDerived(m)
+	assert.Equal(t, map[string]interface{}{
+		"start": json.Number("10"), "end": json.Number("25"), "url": "http://api/x",
+		"latency": json.Number("15"), "service": "api",
+	}, got)
+	assert.Len(t, m, 3)
+
+	plain := map[string]interface{}{"a": "b"}
+	assert.Equal(t, plain, (&Config{Keys: []Key{{Name: "a"}}}).WithDerived(plain))
+}
+
+func TestMakeConfig_Derive(t *testing.T) {
+	file := t.TempDir() + "/template.yaml"
+	assert.NoError(t, os.WriteFile(file, []byte(`keys:
+  - name: latency
+    type: number
+    derive:
+      expression: end - start
+  - name: host
+    type: string
+    derive:
+      from: url
+      regex: ^\w+://([^/:]+)
+`), 0644))
+	c, err := MakeConfig(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "end - start", c.Keys[0].Derive.Expression)
+	assert.Equal(t, [3]string{"", "url", `^\w+://([^/:]+)`}, c.Keys[1].Derive.source())
+	// The derivations are parsed once loaded.
+	assert.NotNil(t, c.Keys[0].Derive.compiled.Load())
+
+	assert.NoError(t, os.WriteFile(file, []byte("keys:\n  - name: latency\n    type: number\n    derive:\n      expression: end -\n"), 0644))
+	_, err = MakeConfig(file)
+	assert.Error(t, err)
+}
@@ -23,10 +23,13 @@ THE SOFTWARE.
 package config
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 
@@ -36,11 +39,110 @@ import (
 const (
 	ParseErr    = "$_parseErr"
 	TextPayload = "message"
+	// Source is the pseudo-field holding the origin of a record, e.g. its file name.
+	Source = "$_source"
+	// Continuation is the pseudo-field holding the lines of a multi-line record after
+	// the first one, such as a stack trace.
+	Continuation = "$_continuation"
+	// Prefix is the pseudo-field holding the text found before the JSON object of a
+	// line, when it isn't split into fields.
+	Prefix = "$_prefix"
+	// Level is the pseudo-field holding the level of a record on the canonical scale,
+	// e.g. WARN for a pino level of 40, see ParseSeverity.
+	Level = "$_level"
+	// Redacted is the pseudo-field listing the fields of a record whose values were
+	// masked, see Redaction.
+	Redacted = "$_redacted"
 )
 
 type Config struct {
-	Keys          []Key  `json:"keys" yaml:"keys"`
-	LastSavedName string `json:"-" yaml:"-"`
+	Keys []Key `json:"keys" yaml:"keys"`
+	// Parser tells how to break lines into fields. Lines are parsed as JSON if nil.
+	Parser *ParserConfig `json:"parser,omitempty" yaml:"parser,omitempty"`
+	// Multiline joins lines into multi-line records before they're parsed.
+	Multiline *MultilineConfig `json:"multiline,omitempty" yaml:"multiline,omitempty"`
+	// Redaction masks sensitive values, along with the rules of the user, see
+	// LoadRedaction.
+	Redaction *Redaction `json:"redact,omitempty" yaml:"redact,omitempty"`
+	// Timezone is the one datetime keys are shown in: local, UTC or an IANA name, see
+	// LoadTimezone. They're shown in the one they were logged in if empty.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// Roles lists extra key names of each role, taking precedence over the user's and
+	// the default ones.
+	Roles         map[Role][]string `json:"roles,omitempty" yaml:"roles,omitempty"`
+	LastSavedName string            `json:"-" yaml:"-"`
+	// Preset names the built-in template the config comes from, if any.
+	Preset string `json:"-" yaml:"-"`
+	// NoAutoPreset keeps deriving the keys from the logs when there's no template,
+	// instead of picking the preset fitting them, see BestPreset.
+	NoAutoPreset bool `json:"-" yaml:"-"`
+
+	// roleAliases is the alias table of the config, merged once, see RoleAliases.
+	roleAliases     map[Role][]string
+	roleAliasesOnce sync.Once
+}
+
+// ParserConfig selects and sets up the parser of the lines, see the parser package.
+type ParserConfig struct {
+	// Type is one of json, lenient-json, logfmt, regex, combined, csv or grok.
+	Type string `json:"type" yaml:"type"`
+	// Pattern is the regular expression of the regex parser, whose named capture groups
+	// become the fields of the record, or the expression of the grok parser, whose
+	// named references do, e.g. %{LOGLEVEL:level}.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Prefix is the regular expression splitting the text before the JSON object of
+	// the lenient-json parser into fields, after its named capture groups.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// Patterns defines grok patterns on top of the bundled ones and the ones found in
+	// ~/.loggo/patterns, keyed by name.
+	Patterns map[string]string `json:"patterns,omitempty" yaml:"patterns,omitempty"`
+	// Columns names the fields of the csv parser. If empty, the first line of each
+	// source is taken as the header row.
+	Columns []string `json:"columns,omitempty" yaml:"columns,omitempty"`
+	// Separator is the field separator of the csv parser, a comma by default.
+	Separator string `json:"separator,omitempty" yaml:"separator,omitempty"`
+}
+
+// MultilineConfig sets up how lines are joined into multi-line records, such as a log
+// line followed by its stack trace. A line is joined to the record before it if it
+// matches Continuation, or if Start is given and it doesn't match Start. With JSON,
+// lines are rather joined into the JSON objects they're pretty-printed over.
+type MultilineConfig struct {
+	// JSON assembles JSON objects spanning several lines, instead of using patterns.
+	JSON bool `json:"json,omitempty" yaml:"json,omitempty"`
+	// Start is the regular expression matching the first line of a record.
+	Start string `json:"start,omitempty" yaml:"start,omitempty"`
+	// Continuation is the regular expression matching the lines that carry on a record.
+	Continuation string `json:"continuation,omitempty" yaml:"continuation,omitempty"`
+	// MaxLines is how many lines a record is cut at, 500 by default.
+	MaxLines int `json:"max-lines,omitempty" yaml:"max-lines,omitempty"`
+	// Timeout is how long a record waits for its next line, a second by default.
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// Validate checks the patterns of the multi-line config.
+func (m *MultilineConfig) Validate() error {
+	if m.JSON {
+		if len(m.Start) > 0 || len(m.Continuation) > 0 {
+			return errors.New("multiline json can't be combined with patterns")
+		}
+		return nil
+	}
+	if len(m.Start) == 0 && len(m.Continuation) == 0 {
+		return errors.New("multiline requires a start or a continuation pattern")
+	}
+	for _, p := range []string{m.Start, m.Continuation} {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid multiline pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// HasTemplate tells whether the keys come from a template, either saved or built-in,
+// rather than being derived from the logs.
+func (c *Config) HasTemplate() bool {
+	return len(c.LastSavedName) > 0 || len(c.Preset) > 0
 }
 
 func (c *Config) Save(fileName string) error {
@@ -63,6 +165,8 @@ func (c *Config) KeyMap() map[string]*Key {
 	nk := make(map[string]*Key)
 	for _, k := range c.Keys {
 		kp := &k
+		// Roles told by names are resolved, for filters to know them without the config.
+		kp.Role = c.RoleOf(kp)
 		nk[k.Name] = kp
 	}
 	return nk
@@ -109,6 +213,15 @@ type Key struct {
 	Color     Color       `json:"color,omitempty" yaml:"color,omitempty"`
 	MaxWidth  int         `json:"max-width,omitempty" yaml:"max-width"`
 	ColorWhen []ColorWhen `json:"color-when,omitempty" yaml:"color-when,omitempty"`
+	// Role tells what the key stands for, if its name doesn't, see RoleAliases.
+	Role Role `json:"role,omitempty" yaml:"role,omitempty"`
+	// Derive computes the value of the key out of other fields, if it isn't one.
+	Derive *Derive `json:"derive,omitempty" yaml:"derive,omitempty"`
+	// Layouts are tried after Layout on datetime keys, for values logged in several
+	// formats, see ParseTime.
+	Layouts []string `json:"layouts,omitempty" yaml:"layouts,omitempty"`
+	// DisplayLayout is how datetime keys are shown, see FormatTime.
+	DisplayLayout string `json:"display-layout,omitempty" yaml:"display-layout,omitempty"`
 }
 
 func GetForegroundColorName(colorable func() *Color, colorIfNone string) string {
@@ -127,55 +240,6 @@ func GetBackgroundColorName(colorable func() *Color, colorIfNone string) string
 	return k.Background
 }
 
-func (k *Key) ExtractValue(m map[string]interface{}) string {
-	kList := strings.Split(k.Name, "/")
-	var val string
-	level := m
-	for i, levelKey := range kList {
-		lv := level[levelKey]
-		if lv == nil {
-			return val
-		}
-		if i == len(kList)-1 {
-			// For the final value, always try to marshal to JSON for consistent formatting
-			b, err := json.Marshal(lv)
-			if err == nil {
-				// Remove surrounding quotes for simple values
-				jsonStr := string(b)
-				if len(jsonStr) > 0 && jsonStr[0] == '"' && jsonStr[len(jsonStr)-1] == '"' {
-					return jsonStr[1 : len(jsonStr)-1]
-				}
-				return jsonStr
-			}
-			return fmt.Sprintf("%+v", lv)
-		}
-		// Check if the current level is a map before casting
-		if nextLevel, ok := lv.(map[string]interface{}); ok {
-			level = nextLevel
-		} else if arr, ok := lv.([]interface{}); ok {
-			// If it's an array, convert it to a JSON string and return
-			b, err := json.Marshal(arr)
-			if err == nil {
-				return string(b)
-			}
-			return fmt.Sprintf("%+v", arr)
-		} else {
-			// If it's neither a map nor an array, just return the string representation
-			b, err := json.Marshal(lv)
-			if err == nil {
-				// Remove surrounding quotes for simple values
-				jsonStr := string(b)
-				if len(jsonStr) > 0 && jsonStr[0] == '"' && jsonStr[len(jsonStr)-1] == '"' {
-					return jsonStr[1 : len(jsonStr)-1]
-				}
-				return jsonStr
-			}
-			return fmt.Sprintf("%+v", lv)
-		}
-	}
-	return val
-}
-
 func MakeConfig(file string) (*Config, error) {
 	var yamlBytes []byte
 	config := Config{}
@@ -191,10 +255,71 @@ func MakeConfig(file string) (*Config, error) {
 	if err := yaml.Unmarshal(yamlBytes, &config); err != nil {
 		return nil, err
 	}
+	if config.Multiline != nil {
+		if err := config.Multiline.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	for role := range config.Roles {
+		if !role.Valid() {
+			return nil, fmt.Errorf("unknown role %s", role)
+		}
+	}
+	for _, k := range config.Keys {
+		if !k.Role.Valid() {
+			return nil, fmt.Errorf("unknown role %s of key %s", k.Role, k.Name)
+		}
+		if _, err := ParsePath(k.Name); err != nil {
+			return nil, err
+		}
+		if k.Derive != nil {
+			if err := k.Derive.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid derive of key %s: %w", k.Name, err)
+			}
+		}
+	}
+	if config.Redaction != nil {
+		if err := config.Redaction.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := LoadTimezone(config.Timezone); err != nil {
+		return nil, err
+	}
 	config.LastSavedName = file
 	return &config, nil
 }
 
+// builtinConfigs are the templates shipped with loggo, by name, see Presets.
+var builtinConfigs = map[string]string{
+	"zap":        zapConfig,
+	"zerolog":    zerologConfig,
+	"logrus":     logrusConfig,
+	"slog":       slogConfig,
+	"pino":       pinoConfig,
+	"bunyan":     bunyanConfig,
+	"serilog":    serilogConfig,
+	"ecs":        ecsConfig,
+	"gcp":        defaultConfig,
+	"cloudwatch": cloudWatchConfig,
+	"azure":      azureConfig,
+	"journald":   journaldConfig,
+}
+
+// MakeBuiltinConfig loads the built-in template called name.
+func MakeBuiltinConfig(name string) (*Config, error) {
+	yamlConfig, ok := builtinConfigs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown built-in template %s", name)
+	}
+	config := Config{}
+	if err := yaml.Unmarshal([]byte(yamlConfig), &config); err != nil {
+		return nil, err
+	}
+	config.Preset = name
+	return &config, nil
+}
+
 type Type string
 
 func (t Type) GetColorName() string {
@@ -267,3 +392,64 @@ const defaultConfig = `keys:
     color:
       foreground: white
       background: black`
+
+const journaldConfig = `keys:
+  - name: timestamp
+    type: datetime
+    layout: 2006-01-02T15:04:05.999999999Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: severity
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: EMERGENCY|ALERT|CRITICAL|ERROR
+        color:
+          foreground: white
+          background: red
+      - match-value: WARNING
+        color:
+          foreground: yellow
+          background: black
+      - match-value: NOTICE|INFO
+        color:
+          foreground: green
+          background: black
+      - match-value: DEBUG
+        color:
+          foreground: blue
+          background: black
+  - name: _HOSTNAME
+    type: string
+    max-width: 16
+    color:
+      foreground: teal
+      background: black
+  - name: _SYSTEMD_UNIT
+    type: string
+    max-width: 25
+    color:
+      foreground: darkgreen
+      background: black
+  - name: SYSLOG_IDENTIFIER
+    type: string
+    max-width: 20
+    color:
+      foreground: white
+      background: black
+  - name: _PID
+    type: number
+    max-width: 8
+    color:
+      foreground: white
+      background: black
+  - name: MESSAGE
+    type: string
+    max-width: 80
+    color:
+      foreground: wheat
+      background: black
+    role: message`
@@ -25,15 +25,17 @@ package config
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestMakeConfig(t *testing.T) {
 	tests := []struct {
 		name       string
 		givenFile  string
-		wants      Config
+		wants      *Config
 		wantsError bool
 	}{
 		{
@@ -48,6 +50,11 @@ func TestMakeConfig(t *testing.T) {
 			wants:      defConfig,
 			wantsError: true,
 		},
+		{
+			name:      "With parser",
+			givenFile: "../config-sample/logfmt.yaml",
+			wants:     logfmtConfig,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -57,7 +64,7 @@ func TestMakeConfig(t *testing.T) {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, test.wants, *c)
+				assert.Equal(t, test.wants, c)
 			}
 		})
 	}
@@ -94,6 +101,72 @@ func TestKey_ExtractValue(t *testing.T) {
 			givenJson: []byte(`{"a":{"b":{"value": 1}}}`),
 			wantValue: "1",
 		},
+		{
+			name:      "Missing key",
+			givenKey:  &Key{Name: "a/c"},
+			givenJson: []byte(`{"a":{"b":1}}`),
+			wantValue: "",
+		},
+		{
+			name:      "Object value",
+			givenKey:  &Key{Name: "a"},
+			givenJson: []byte(`{"a":{"b":1}}`),
+			wantValue: `{"b":1}`,
+		},
+		{
+			name:      "Array index",
+			givenKey:  &Key{Name: "items/1/id"},
+			givenJson: []byte(`{"items":[{"id":"a"},{"id":"b"}]}`),
+			wantValue: "b",
+		},
+		{
+			name:      "Negative array index",
+			givenKey:  &Key{Name: "items/-1/id"},
+			givenJson: []byte(`{"items":[{"id":"a"},{"id":"b"}]}`),
+			wantValue: "b",
+		},
+		{
+			name:      "Array index out of range",
+			givenKey:  &Key{Name: "items/2/id"},
+			givenJson: []byte(`{"items":[{"id":"a"},{"id":"b"}]}`),
+			wantValue: "",
+		},
+		{
+			name:      "Wildcard",
+			givenKey:  &Key{Name: "items/*/id"},
+			givenJson: []byte(`{"items":[{"id":"a"},{"name":"x"},{"id":2}]}`),
+			wantValue: `["a",2]`,
+		},
+		{
+			name:      "Wildcard over an object",
+			givenKey:  &Key{Name: "labels/*"},
+			givenJson: []byte(`{"labels":{"b":"y","a":"x"}}`),
+			wantValue: `["x","y"]`,
+		},
+		{
+			name:      "Numeric object key",
+			givenKey:  &Key{Name: "codes/404"},
+			givenJson: []byte(`{"codes":{"404":"not found"}}`),
+			wantValue: "not found",
+		},
+		{
+			name:      "Quoted key",
+			givenKey:  &Key{Name: `jsonPayload/"logging.googleapis.com/trace"`},
+			givenJson: []byte(`{"jsonPayload":{"logging.googleapis.com/trace":"t1"}}`),
+			wantValue: "t1",
+		},
+		{
+			name:      "Escaped slash",
+			givenKey:  &Key{Name: `jsonPayload/logging.googleapis.com\/trace`},
+			givenJson: []byte(`{"jsonPayload":{"logging.googleapis.com/trace":"t1"}}`),
+			wantValue: "t1",
+		},
+		{
+			name:      "Top-level key with a slash",
+			givenKey:  &Key{Name: "logging.googleapis.com/trace"},
+			givenJson: []byte(`{"logging.googleapis.com/trace":"t1"}`),
+			wantValue: "t1",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -106,7 +179,33 @@ func TestKey_ExtractValue(t *testing.T) {
 	}
 }
 
-var defConfig = Config{
+var logfmtConfig = &Config{
+	Parser: &ParserConfig{Type: "logfmt"},
+	Keys: []Key{
+		{
+			Name:   "time",
+			Type:   TypeDateTime,
+			Layout: "2006-01-02T15:04:05Z07:00",
+			Color:  Color{Foreground: "purple", Background: "black"},
+		},
+		{
+			Name:  "level",
+			Type:  TypeString,
+			Color: Color{Foreground: "white", Background: "black"},
+			ColorWhen: []ColorWhen{
+				{MatchValue: "error", Color: Color{Foreground: "white", Background: "red"}},
+				{MatchValue: "warn", Color: Color{Foreground: "yellow", Background: "black"}},
+			},
+		},
+		{
+			Name:  "msg",
+			Type:  TypeString,
+			Color: Color{Foreground: "white", Background: "black"},
+		},
+	},
+}
+
+var defConfig = &Config{
 	Keys: []Key{
 		{
 			Name:   "timestamp",
@@ -181,3 +280,94 @@ var defConfig = Config{
 		},
 	},
 }
+
+func TestMakeConfigFromSample_Source(t *testing.T) {
+	tests := []struct {
+		name        string
+		givenSample []map[string]interface{}
+		wantsSource bool
+	}{
+		{
+			name: "single source is not shown",
+			givenSample: []map[string]interface{}{
+				{"message": "a", Source: "a.log"},
+				{"message": "b", Source: "a.log"},
+			},
+			wantsSource: false,
+		},
+		{
+			name: "multiple sources come first",
+			givenSample: []map[string]interface{}{
+				{"message": "a", Source: "a.log"},
+				{"message": "b", Source: "b.log"},
+			},
+			wantsSource: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := MakeConfigFromSample(test.givenSample)
+			if test.wantsSource {
+				assert.Equal(t, Source, c.Keys[0].Name)
+			} else {
+				for _, k := range c.Keys {
+					assert.NotEqual(t, Source, k.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMakeConfigFromSample_Syslog(t *testing.T) {
+	sample := []map[string]interface{}{{
+		"facility":       "auth",
+		"severity":       "CRITICAL",
+		"timestamp":      "2022-10-11T22:14:15Z",
+		"hostname":       "mymachine",
+		"appName":        "su",
+		"procId":         "42",
+		"msgId":          "ID47",
+		"structuredData": map[string]interface{}{"meta": map[string]interface{}{"seq": "7"}},
+		"message":        "'su root' failed",
+	}}
+	c, _ := MakeConfigFromSample(sample)
+	var names []string
+	for _, k := range c.Keys {
+		names = append(names, k.Name)
+	}
+	assert.Equal(t, []string{"timestamp", "severity", "hostname", "appName", "procId", "msgId",
+		"facility", "message", "structuredData"}, names)
+}
+
+func TestMakeBuiltinConfig(t *testing.T) {
+	c, err := MakeBuiltinConfig("gcp")
+	assert.NoError(t, err)
+	assert.Equal(t, "gcp", c.Preset)
+	assert.Len(t, c.Keys, 5)
+	assert.True(t, c.HasTemplate())
+
+	c, err = MakeBuiltinConfig("journald")
+	assert.NoError(t, err)
+	assert.Equal(t, "journald", c.Preset)
+	assert.Equal(t, "timestamp", c.Keys[0].Name)
+	assert.Equal(t, "MESSAGE", c.Keys[len(c.Keys)-1].Name)
+
+	_, err = MakeBuiltinConfig("foo")
+	assert.Error(t, err)
+}
+
+func TestMultilineConfig(t *testing.T) {
+	c := Config{}
+	assert.NoError(t, yaml.Unmarshal([]byte("multiline:\n  start: ^\\d{4}-\n  max-lines: 100\n  timeout: 2s\n"), &c))
+	assert.Equal(t, &MultilineConfig{Start: `^\d{4}-`, MaxLines: 100, Timeout: 2 * time.Second}, c.Multiline)
+	assert.NoError(t, c.Multiline.Validate())
+
+	b, err := yaml.Marshal(c.Multiline)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "timeout: 2s")
+
+	assert.Error(t, (&MultilineConfig{}).Validate())
+	assert.Error(t, (&MultilineConfig{Continuation: "("}).Validate())
+	assert.NoError(t, (&MultilineConfig{JSON: true}).Validate())
+	assert.Error(t, (&MultilineConfig{JSON: true, Start: "^{"}).Validate())
+}
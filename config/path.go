@@ -0,0 +1,244 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Path tells where a value is nested in a record, as parsed from a key name. Steps are
+// separated by slashes, e.g. jsonPayload/message, each step being either:
+//   - the key of an object, in which slashes and backslashes are escaped as \/ and \\,
+//     or else quoted, e.g. jsonPayload/"logging.googleapis.com/trace", with \" and \\
+//     escaped within the quotes;
+//   - the index of an array, counting back from its end if negative, e.g. items/-1/id;
+//     numbers also stand for the keys of objects, unless quoted;
+//   - a wildcard, *, standing for every element of an array or value of an object,
+//     e.g. items/*/id.
+type Path []pathStep
+
+type pathStep struct {
+	key string
+	// index is set if the key is a number, standing for an index as well.
+	index    *int
+	wildcard bool
+}
+
+// ParsePath parses a key name into a path.
+func ParsePath(name string) (Path, error) {
+	var path Path
+	var sb strings.Builder
+	step := pathStep{}
+	// literal is set once anything is escaped, so that \* is no wildcard
+	quoted, closed, escaped, literal := false, false, false, false
+	endStep := func() {
+		step.key = sb.String()
+		if !quoted && !literal {
+			if step.key == "*" {
+				step.wildcard = true
+			} else if i, err := strconv.Atoi(step.key); err == nil {
+				step.index = &i
+			}
+		}
+		path = append(path, step)
+		sb.Reset()
+		step = pathStep{}
+		quoted, closed, literal = false, false, false
+	}
+	for i, r := range name {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, literal = true, true
+		case quoted && !closed:
+			if r == '"' {
+				closed = true
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '/':
+			endStep()
+		case closed:
+			return nil, fmt.Errorf("invalid path %s: unexpected %q after quote at %d", name, r, i)
+		case r == '"' && sb.Len() == 0:
+			quoted = true
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("invalid path %s: trailing backslash", name)
+	}
+	if quoted && !closed {
+		return nil, fmt.Errorf("invalid path %s: unterminated quote", name)
+	}
+	endStep()
+	return path, nil
+}
+
+// HasWildcard tells whether p may lead to more than one value.
+func (p Path) HasWildcard() bool {
+	for _, step := range p {
+		if step.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the values found at p in m. There's one at most, unless p has
+// wildcards, in which case they're ordered by index, or by key for objects.
+func (p Path) Lookup(m map[string]interface{}) []interface{} {
+	return lookup(m, p)
+}
+
+func lookup(v interface{}, steps []pathStep) []interface{} {
+	if len(steps) == 0 {
+		return []interface{}{v}
+	}
+	step, rest := steps[0], steps[1:]
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if step.wildcard {
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var found []interface{}
+			for _, k := range keys {
+				found = append(found, lookup(val[k], rest)...)
+			}
+			return found
+		}
+		if next, ok := val[step.key]; ok && next != nil {
+			return lookup(next, rest)
+		}
+	case []interface{}:
+		if step.wildcard {
+			var found []interface{}
+			for _, next := range val {
+				found = append(found, lookup(next, rest)...)
+			}
+			return found
+		}
+		if step.index != nil {
+			i := *step.index
+			if i < 0 {
+				i += len(val)
+			}
+			if i >= 0 && i < len(val) && val[i] != nil {
+				return lookup(val[i], rest)
+			}
+		}
+	}
+	return nil
+}
+
+var pathCache sync.Map
+
+// path returns the parsed name of the key, or nil if it's no valid path.
+func (k *Key) path() Path {
+	if p, ok := pathCache.Load(k.Name); ok {
+		return p.(Path)
+	}
+	p, err := ParsePath(k.Name)
+	if err != nil {
+		p = nil
+	}
+	pathCache.Store(k.Name, p)
+	return p
+}
+
+// ExtractValues returns the values found at the path the key's name stands for, see
+// Path, formatted as ExtractValue does. A name that isn't found as a path but is the
+// name of a top-level key is taken literally, e.g. logging.googleapis.com/trace.
+// Derived keys have their derived value, if any, see Derive.
+func (k *Key) ExtractValues(m map[string]interface{}) []string {
+	if k.Derive != nil {
+		if v, ok := k.Derive.Value(m); ok {
+			return []string{v}
+		}
+		return nil
+	}
+	found, _ := k.lookup(m)
+	values := make([]string, len(found))
+	for i, v := range found {
+		values[i] = formatValue(v)
+	}
+	return values
+}
+
+// ExtractValue returns the value found at the path the key's name stands for, or an
+// empty string if there's none. Values other than strings are formatted as JSON. With
+// wildcards, the values found are rather formatted as a JSON array. Derived keys have
+// their derived value instead.
+func (k *Key) ExtractValue(m map[string]interface{}) string {
+	if k.Derive != nil {
+		v, _ := k.Derive.Value(m)
+		return v
+	}
+	found, wildcard := k.lookup(m)
+	switch {
+	case len(found) == 0:
+		return ""
+	case wildcard:
+		return formatValue(found)
+	}
+	return formatValue(found[0])
+}
+
+// lookup returns the values found for the key in m, telling whether they were found
+// through wildcards.
+func (k *Key) lookup(m map[string]interface{}) ([]interface{}, bool) {
+	if p := k.path(); p != nil {
+		if found := p.Lookup(m); len(found) > 0 {
+			return found, p.HasWildcard()
+		}
+	}
+	if v, ok := m[k.Name]; ok && v != nil {
+		return []interface{}{v}, false
+	}
+	return nil, false
+}
+
+// formatValue marshals v to JSON for consistent formatting, without the quotes of
+// strings.
+func formatValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	s := string(b)
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		wants     Path
+		wantsErr  bool
+		wildcards bool
+	}{
+		{name: "a", wants: Path{{key: "a"}}},
+		{name: "a/b", wants: Path{{key: "a"}, {key: "b"}}},
+		{name: "items/-1", wants: Path{{key: "items"}, {key: "-1", index: intPtr(-1)}}},
+		{name: "items/*/id", wants: Path{{key: "items"}, {key: "*", wildcard: true}, {key: "id"}}, wildcards: true},
+		{name: `a/"b/c"`, wants: Path{{key: "a"}, {key: "b/c"}}},
+		{name: `"0"/"*"`, wants: Path{{key: "0"}, {key: "*"}}},
+		{name: `"say \"hi\""`, wants: Path{{key: `say "hi"`}}},
+		{name: `a\/b/c\\d`, wants: Path{{key: "a/b"}, {key: `c\d`}}},
+		{name: `\*`, wants: Path{{key: "*"}}},
+		{name: `a"b`, wants: Path{{key: `a"b`}}},
+		{name: `"a"b`, wantsErr: true},
+		{name: `"a`, wantsErr: true},
+		{name: `a\`, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := ParsePath(test.name)
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, p)
+			assert.Equal(t, test.wildcards, p.HasWildcard())
+		})
+	}
+}
+
+func TestKey_ExtractValues(t *testing.T) {
+	m := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": "a"},
+			map[string]interface{}{"id": 2.0},
+		},
+	}
+	assert.Equal(t, []string{"a", "2"}, (&Key{Name: "items/*/id"}).ExtractValues(m))
+	assert.Equal(t, []string{"2"}, (&Key{Name: "items/1/id"}).ExtractValues(m))
+	assert.Empty(t, (&Key{Name: "items/*/name"}).ExtractValues(m))
+}
+
+func TestKey_ExtractValue_ExactNumbers(t *testing.T) {
+	m := map[string]interface{}{
+		"id":   json.Number("1234567890123456789"),
+		"ts":   json.Number("1759320000123456789"),
+		"ids":  []interface{}{json.Number("9007199254740993"), json.Number("1.5e3")},
+		"size": 1.234567890123e+24,
+	}
+	assert.Equal(t, "1234567890123456789", (&Key{Name: "id"}).ExtractValue(m))
+	assert.Equal(t, "1759320000123456789", (&Key{Name: "ts"}).ExtractValue(m))
+	assert.Equal(t, "[9007199254740993,1.5e3]", (&Key{Name: "ids"}).ExtractValue(m))
+	assert.Equal(t, "[9007199254740993,1.5e3]", (&Key{Name: "ids/*"}).ExtractValue(m))
+	assert.Equal(t, "1.234567890123e+24", (&Key{Name: "size"}).ExtractValue(m))
+}
+
+func TestMakeConfig_InvalidPath(t *testing.T) {
+	file := t.TempDir() + "/template.yaml"
+	assert.NoError(t, os.WriteFile(file, []byte("keys:\n  - name: '\"a'\n    type: string\n"), 0644))
+	_, err := MakeConfig(file)
+	assert.Error(t, err)
+}
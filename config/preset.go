@@ -0,0 +1,682 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Preset is a built-in template laid out for a well-known log format, along with the
+// fingerprint telling the records of that format apart.
+type Preset struct {
+	Name string
+	// Description tells the log format, e.g. for listing the presets.
+	Description string
+	fingerprint []fingerprintKey
+}
+
+// fingerprintKey is a key found in the records of a preset. The records must have all
+// the required keys, whose values must match if there's a pattern.
+type fingerprintKey struct {
+	name     string
+	required bool
+	match    *regexp.Regexp
+}
+
+func required(name string) fingerprintKey {
+	return fingerprintKey{name: name, required: true}
+}
+
+func requiredMatch(name, pattern string) fingerprintKey {
+	return fingerprintKey{name: name, required: true, match: regexp.MustCompile(pattern)}
+}
+
+func optional(name string) fingerprintKey {
+	return fingerprintKey{name: name}
+}
+
+const numericLevel = `^\d+$`
+
+// presets are the built-in templates, in the order they're listed.
+var presets = []Preset{
+	{Name: "zap", Description: "Go uber-go/zap JSON", fingerprint: []fingerprintKey{
+		requiredMatch("level", `^(debug|info|warn|error|dpanic|panic|fatal)$`),
+		required("ts"), required("msg"),
+		optional("caller"), optional("logger"), optional("stacktrace"),
+	}},
+	{Name: "zerolog", Description: "Go rs/zerolog JSON", fingerprint: []fingerprintKey{
+		requiredMatch("level", `^(trace|debug|info|warn|error|fatal|panic)$`),
+		required("time"), required("message"),
+		optional("caller"), optional("error"),
+	}},
+	{Name: "logrus", Description: "Go sirupsen/logrus JSON", fingerprint: []fingerprintKey{
+		requiredMatch("level", `^(trace|debug|info|warning|error|fatal|panic)$`),
+		required("time"), required("msg"),
+		optional("func"), optional("file"), optional("error"),
+	}},
+	{Name: "slog", Description: "Go log/slog JSON", fingerprint: []fingerprintKey{
+		requiredMatch("level", `^(DEBUG|INFO|WARN|ERROR)([+-]\d+)?$`),
+		required("time"), required("msg"),
+		optional("source"),
+	}},
+	{Name: "pino", Description: "Node.js pino", fingerprint: []fingerprintKey{
+		requiredMatch("level", numericLevel),
+		required("time"), required("pid"), required("hostname"), required("msg"),
+		optional("err"), optional("reqId"),
+	}},
+	{Name: "bunyan", Description: "Node.js bunyan", fingerprint: []fingerprintKey{
+		requiredMatch("level", numericLevel),
+		required("time"), required("pid"), required("hostname"), required("msg"),
+		required("name"), required("v"),
+		optional("err"), optional("src"),
+	}},
+	{Name: "serilog", Description: ".NET Serilog compact JSON (CLEF)", fingerprint: []fingerprintKey{
+		required("@t"),
+		optional("@mt"), optional("@m"), optional("@l"), optional("@x"), optional("@i"),
+		optional("@r"), optional("SourceContext"),
+	}},
+	{Name: "ecs", Description: "Elastic Common Schema", fingerprint: []fingerprintKey{
+		required("@timestamp"), required("ecs.version"),
+		optional("log.level"), optional("message"), optional("log.logger"),
+		optional("service.name"), optional("trace.id"), optional("span.id"),
+	}},
+	{Name: "gcp", Description: "Google Cloud Logging entries", fingerprint: []fingerprintKey{
+		required("logName"), required("resource"),
+		optional("severity"), optional("timestamp"), optional("insertId"),
+		optional("receiveTimestamp"), optional("jsonPayload"), optional("textPayload"),
+		optional("trace"),
+	}},
+	{Name: "cloudwatch", Description: "AWS CloudWatch Logs events", fingerprint: []fingerprintKey{
+		required("timestamp"), required("message"), required("logStreamName"),
+		optional("ingestionTime"), optional("eventId"), optional("logGroupName"),
+	}},
+	{Name: "azure", Description: "Azure Monitor resource logs", fingerprint: []fingerprintKey{
+		required("time"), required("resourceId"), required("category"),
+		optional("operationName"), optional("level"), optional("properties"),
+		optional("resultType"), optional("correlationId"), optional("callerIpAddress"),
+	}},
+	{Name: "journald", Description: "systemd journal, as read with --journald"},
+}
+
+// NoPreset is the name standing for no preset, i.e. the keys being derived from the logs.
+const NoPreset = "none"
+
+// Presets lists the built-in templates.
+func Presets() []Preset {
+	return presets
+}
+
+// PresetMatch tells how well a preset fits a sample of records.
+type PresetMatch struct {
+	Preset string
+	// Records is how many records of the sample fit the preset.
+	Records int
+	// Score is how many fingerprint keys of the preset the records have, on average,
+	// counting none for the records that don't fit. The more specific the preset, the
+	// higher the score.
+	Score float64
+}
+
+// MatchPresets lists the presets fitting any record of sample, best first. Records that
+// failed parsing are left out.
+func MatchPresets(sample []map[string]interface{}) []PresetMatch {
+	records := parsedRecords(sample)
+	var matches []PresetMatch
+	for _, p := range presets {
+		if len(p.fingerprint) == 0 {
+			continue
+		}
+		pm := PresetMatch{Preset: p.Name}
+		total := 0
+		for _, m := range records {
+			if n := p.matchKeys(m); n > 0 {
+				pm.Records++
+				total += n
+			}
+		}
+		if pm.Records == 0 {
+			continue
+		}
+		pm.Score = float64(total) / float64(len(records))
+		matches = append(matches, pm)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// BestPreset picks the preset fitting most of sample, if any, see MatchPresets.
+func BestPreset(sample []map[string]interface{}) (string, bool) {
+	matches := MatchPresets(sample)
+	if len(matches) == 0 {
+		return "", false
+	}
+	best := matches[0]
+	// Half of the records might be anything, such as a banner printed on start.
+	if best.Records*2 <= len(parsedRecords(sample)) {
+		return "", false
+	}
+	return best.Preset, true
+}
+
+func parsedRecords(sample []map[string]interface{}) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, m := range sample {
+		if _, ok := m[ParseErr]; !ok {
+			records = append(records, m)
+		}
+	}
+	return records
+}
+
+// matchKeys counts the fingerprint keys found in m, or returns zero if m misses any
+// required one.
+func (p *Preset) matchKeys(m map[string]interface{}) int {
+	n := 0
+	for _, fk := range p.fingerprint {
+		v, ok := m[fk.name]
+		if ok && fk.match != nil {
+			ok = fk.match.MatchString(fmt.Sprint(v))
+		}
+		if ok {
+			n++
+		} else if fk.required {
+			return 0
+		}
+	}
+	return n
+}
+
+const zapConfig = `keys:
+  - name: ts
+    type: datetime
+    display-layout: 2006-01-02T15:04:05.000Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(error|dpanic|panic|fatal)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^warn
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^info$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(debug|trace)$
+        color:
+          foreground: blue
+          background: black
+  - name: logger
+    type: string
+    max-width: 20
+    color:
+      foreground: darkgreen
+      background: black
+  - name: caller
+    type: string
+    max-width: 25
+    color:
+      foreground: teal
+      background: black
+  - name: msg
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black`
+
+const zerologConfig = `keys:
+  - name: time
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(error|dpanic|panic|fatal)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^warn
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^info$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(debug|trace)$
+        color:
+          foreground: blue
+          background: black
+  - name: caller
+    type: string
+    max-width: 25
+    color:
+      foreground: teal
+      background: black
+  - name: message
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black
+  - name: error
+    type: string
+    max-width: 40
+    color:
+      foreground: red
+      background: black`
+
+const logrusConfig = `keys:
+  - name: time
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(error|dpanic|panic|fatal)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^warn
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^info$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(debug|trace)$
+        color:
+          foreground: blue
+          background: black
+  - name: func
+    type: string
+    max-width: 25
+    color:
+      foreground: teal
+      background: black
+  - name: msg
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black
+  - name: error
+    type: string
+    max-width: 40
+    color:
+      foreground: red
+      background: black`
+
+const slogConfig = `keys:
+  - name: time
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^ERROR
+        color:
+          foreground: white
+          background: red
+      - match-value: ^WARN
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^INFO
+        color:
+          foreground: green
+          background: black
+      - match-value: ^DEBUG
+        color:
+          foreground: blue
+          background: black
+  - name: msg
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black`
+
+const pinoConfig = `keys:
+  - name: time
+    type: datetime
+    display-layout: 2006-01-02T15:04:05.000Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: number
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(50|60)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^40$
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^30$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(10|20)$
+        color:
+          foreground: blue
+          background: black
+  - name: hostname
+    type: string
+    max-width: 16
+    color:
+      foreground: teal
+      background: black
+  - name: pid
+    type: number
+    max-width: 8
+    color:
+      foreground: white
+      background: black
+  - name: msg
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black`
+
+const bunyanConfig = `keys:
+  - name: time
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: number
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(50|60)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^40$
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^30$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(10|20)$
+        color:
+          foreground: blue
+          background: black
+  - name: name
+    type: string
+    max-width: 20
+    color:
+      foreground: darkgreen
+      background: black
+  - name: hostname
+    type: string
+    max-width: 16
+    color:
+      foreground: teal
+      background: black
+  - name: pid
+    type: number
+    max-width: 8
+    color:
+      foreground: white
+      background: black
+  - name: msg
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black`
+
+const serilogConfig = `keys:
+  - name: "@t"
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: "@l"
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(Fatal|Error)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^Warning$
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^(Information)?$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(Debug|Verbose)$
+        color:
+          foreground: blue
+          background: black
+  - name: SourceContext
+    type: string
+    max-width: 25
+    color:
+      foreground: darkgreen
+      background: black
+  - name: "@m"
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black
+  - name: "@mt"
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black
+  - name: "@x"
+    type: string
+    max-width: 40
+    color:
+      foreground: red
+      background: black`
+
+const ecsConfig = `keys:
+  - name: "@timestamp"
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: log.level
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(error|dpanic|panic|fatal)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^warn
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^info$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^(debug|trace)$
+        color:
+          foreground: blue
+          background: black
+  - name: service.name
+    type: string
+    max-width: 20
+    color:
+      foreground: darkgreen
+      background: black
+  - name: log.logger
+    type: string
+    max-width: 25
+    color:
+      foreground: teal
+      background: black
+  - name: trace.id
+    type: string
+    max-width: 32
+    color:
+      foreground: white
+      background: black
+  - name: message
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black`
+
+const cloudWatchConfig = `keys:
+  - name: timestamp
+    type: datetime
+    display-layout: 2006-01-02T15:04:05.000Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: logStreamName
+    type: string
+    max-width: 30
+    color:
+      foreground: darkgreen
+      background: black
+  - name: message
+    type: string
+    max-width: 100
+    color:
+      foreground: white
+      background: black`
+
+const azureConfig = `keys:
+  - name: time
+    type: datetime
+    layout: 2006-01-02T15:04:05Z07:00
+    color:
+      foreground: purple
+      background: black
+  - name: level
+    type: string
+    color:
+      foreground: white
+      background: black
+    color-when:
+      - match-value: ^(Critical|Error)$
+        color:
+          foreground: white
+          background: red
+      - match-value: ^Warning$
+        color:
+          foreground: yellow
+          background: black
+      - match-value: ^(Informational|Information)$
+        color:
+          foreground: green
+          background: black
+      - match-value: ^Verbose$
+        color:
+          foreground: blue
+          background: black
+  - name: category
+    type: string
+    max-width: 20
+    color:
+      foreground: darkgreen
+      background: black
+  - name: operationName
+    type: string
+    max-width: 30
+    color:
+      foreground: teal
+      background: black
+  - name: resultType
+    type: string
+    max-width: 12
+    color:
+      foreground: white
+      background: black
+  - name: resourceId
+    type: string
+    max-width: 40
+    color:
+      foreground: white
+      background: black
+  - name: resultDescription
+    type: string
+    max-width: 80
+    color:
+      foreground: white
+      background: black
+    role: message`
@@ -0,0 +1,153 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresets(t *testing.T) {
+	for _, p := range Presets() {
+		t.Run(p.Name, func(t *testing.T) {
+			c, err := MakeBuiltinConfig(p.Name)
+			assert.NoError(t, err)
+			assert.Equal(t, p.Name, c.Preset)
+			assert.NotEmpty(t, c.Keys)
+			assert.NotNil(t, c.KeyFor(RoleTimestamp))
+			assert.NotNil(t, c.KeyFor(RoleMessage))
+		})
+	}
+}
+
+func TestMatchPresets(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		wants  string
+		second string
+	}{
+		{
+			name:  "zap",
+			line:  `{"level":"info","ts":1696161600.123,"caller":"api/main.go:42","msg":"listening"}`,
+			wants: "zap",
+		},
+		{
+			name:  "zerolog",
+			line:  `{"level":"warn","time":"2026-10-01T12:00:00Z","message":"slow query"}`,
+			wants: "zerolog",
+		},
+		{
+			name:  "logrus",
+			line:  `{"level":"warning","msg":"slow query","time":"2026-10-01T12:00:00Z"}`,
+			wants: "logrus",
+		},
+		{
+			name:  "slog",
+			line:  `{"time":"2026-10-01T12:00:00.123Z","level":"INFO","msg":"listening","port":8080}`,
+			wants: "slog",
+		},
+		{
+			name:  "pino",
+			line:  `{"level":30,"time":1696161600123,"pid":42,"hostname":"api-1","msg":"listening"}`,
+			wants: "pino",
+		},
+		{
+			name:   "bunyan",
+			line:   `{"name":"api","hostname":"api-1","pid":42,"level":30,"msg":"listening","time":"2026-10-01T12:00:00.123Z","v":0}`,
+			wants:  "bunyan",
+			second: "pino",
+		},
+		{
+			name:  "serilog",
+			line:  `{"@t":"2026-10-01T12:00:00.1234567Z","@mt":"Listening on {Port}","Port":8080}`,
+			wants: "serilog",
+		},
+		{
+			name:  "ecs",
+			line:  `{"@timestamp":"2026-10-01T12:00:00.123Z","log.level":"info","message":"listening","ecs.version":"1.6.0"}`,
+			wants: "ecs",
+		},
+		{
+			name:  "gcp",
+			line:  `{"insertId":"x1","logName":"projects/p/logs/stdout","resource":{"type":"k8s_container"},"severity":"INFO","timestamp":"2026-10-01T12:00:00Z","jsonPayload":{"message":"listening"}}`,
+			wants: "gcp",
+		},
+		{
+			name:  "cloudwatch",
+			line:  `{"logStreamName":"api/1","timestamp":1696161600123,"message":"listening","ingestionTime":1696161600200,"eventId":"37"}`,
+			wants: "cloudwatch",
+		},
+		{
+			name:  "azure",
+			line:  `{"time":"2026-10-01T12:00:00Z","resourceId":"/SUBSCRIPTIONS/X/PROVIDERS/MICROSOFT.WEB/SITES/API","category":"AppServiceHTTPLogs","operationName":"Microsoft.Web/sites/log","level":"Informational"}`,
+			wants: "azure",
+		},
+		{
+			name: "Unknown",
+			line: `{"when":"2026-10-01T12:00:00Z","text":"listening"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var m map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(test.line), &m))
+			matches := MatchPresets([]map[string]interface{}{m})
+			best, ok := BestPreset([]map[string]interface{}{m})
+			if len(test.wants) == 0 {
+				assert.Empty(t, matches)
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, test.wants, best)
+			assert.Equal(t, test.wants, matches[0].Preset)
+			assert.Equal(t, 1, matches[0].Records)
+			if len(test.second) > 0 {
+				assert.Equal(t, test.second, matches[1].Preset)
+				assert.Less(t, matches[1].Score, matches[0].Score)
+			}
+		})
+	}
+}
+
+func TestBestPreset_Sample(t *testing.T) {
+	zap := map[string]interface{}{"level": "info", "ts": 1696161600.0, "msg": "listening"}
+	other := map[string]interface{}{"text": "starting up"}
+	parseErr := map[string]interface{}{ParseErr: "invalid character", TextPayload: "banner"}
+
+	best, ok := BestPreset([]map[string]interface{}{parseErr, zap, zap, other})
+	assert.True(t, ok)
+	assert.Equal(t, "zap", best)
+
+	// Half of the records isn't enough.
+	_, ok = BestPreset([]map[string]interface{}{zap, other})
+	assert.False(t, ok)
+	matches := MatchPresets([]map[string]interface{}{zap, other})
+	assert.Equal(t, []PresetMatch{{Preset: "zap", Records: 1, Score: 1.5}}, matches)
+
+	_, ok = BestPreset(nil)
+	assert.False(t, ok)
+}
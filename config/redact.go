@@ -0,0 +1,460 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RedactReplace = "replace"
+	RedactHash    = "hash"
+	// DefaultReplacement is what values are replaced with by default.
+	DefaultReplacement = "[REDACTED]"
+
+	redactFile = "redact.yaml"
+)
+
+// Redaction sets up how sensitive values, such as emails or tokens, are masked before
+// records are kept, so that they neither show on screen nor end up in the clipboard.
+type Redaction struct {
+	Rules []RedactionRule `json:"rules" yaml:"rules"`
+	// AllowReveal keeps the raw records along with the redacted ones, for their values
+	// to be revealed on demand. Raw values are dropped straight away otherwise.
+	AllowReveal bool `json:"allow-reveal,omitempty" yaml:"allow-reveal,omitempty"`
+	// HashKey keys the digests of the hash strategy, for them to match across sessions.
+	// A random key is drawn for each session otherwise. Either way, the values can't be
+	// told back from their digests without the key.
+	HashKey string `json:"hash-key,omitempty" yaml:"hash-key,omitempty"`
+}
+
+// RedactionRule tells which values to mask, and how. Fields found at Keys are masked
+// whole, unless there's a Pattern or a Detector, in which case only their matches are,
+// either in the fields found at Keys and within them, or else in every field.
+type RedactionRule struct {
+	// Name describes the rule, e.g. customer emails.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Keys lists the paths of the fields the rule applies to, see Path. Wildcards stand
+	// for any key or index, but negative indices aren't supported.
+	Keys []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+	// Pattern is the regular expression matching the values to mask.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Detector names a built-in pattern instead, see Detectors.
+	Detector string `json:"detector,omitempty" yaml:"detector,omitempty"`
+	// Strategy is either replace, the default, or hash, which replaces values with a
+	// keyed digest of theirs, so that equal values can still be told apart from others,
+	// see HashKey.
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	// Replacement replaces values with the replace strategy, DefaultReplacement if empty.
+	Replacement string `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+}
+
+type detector struct {
+	re *regexp.Regexp
+	// valid further checks the matches, if set.
+	valid func(match string) bool
+}
+
+var detectors = map[string]detector{
+	"email":   {re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)},
+	"card":    {re: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`), valid: luhn},
+	"jwt":     {re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)},
+	"bearer":  {re: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)},
+	"aws-key": {re: regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`)},
+	"ipv4": {re: regexp.MustCompile(
+		`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+}
+
+// Detectors lists the names of the built-in patterns: email, card (payment card
+// numbers passing the Luhn check), jwt, bearer (authorization tokens), aws-key and ipv4.
+func Detectors() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// luhn tells whether the digits of s pass the Luhn check of payment card numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// LoadRedaction reads the redaction rules of the user in ~/.loggo/redact.yaml, which
+// apply along with the ones of any template. It returns nil if there's no such file.
+func LoadRedaction() (*Redaction, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path.Join(home, parentPath, redactFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	r := &Redaction{}
+	if err := yaml.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", redactFile, err)
+	}
+	if _, err := r.compile(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", redactFile, err)
+	}
+	return r, nil
+}
+
+// AddRedaction adds the rules of r to the ones of the config, revealing being allowed
+// if either allows it. The hash key of the config is kept if it has one.
+func (c *Config) AddRedaction(r *Redaction) {
+	if r == nil {
+		return
+	}
+	if c.Redaction == nil {
+		c.Redaction = &Redaction{}
+	}
+	c.Redaction.Rules = append(c.Redaction.Rules, r.Rules...)
+	c.Redaction.AllowReveal = c.Redaction.AllowReveal || r.AllowReveal
+	if len(c.Redaction.HashKey) == 0 {
+		c.Redaction.HashKey = r.HashKey
+	}
+}
+
+// Validate checks the rules.
+func (r *Redaction) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+// Redactor builds the redactor applying the redaction rules of the config along with
+// the user's, see LoadRedaction. It returns nil if there's no rule.
+func (c *Config) Redactor() (*Redactor, error) {
+	user, err := LoadRedaction()
+	if err != nil {
+		return nil, err
+	}
+	all := &Config{}
+	all.AddRedaction(c.Redaction)
+	all.AddRedaction(user)
+	if all.Redaction == nil || len(all.Redaction.Rules) == 0 {
+		return nil, nil
+	}
+	return all.Redaction.compile()
+}
+
+func (r *Redaction) compile() (*Redactor, error) {
+	red := &Redactor{allowReveal: r.AllowReveal, hashKey: []byte(r.HashKey)}
+	if len(red.hashKey) == 0 {
+		red.hashKey = sessionHashKey()
+	}
+	for i, rule := range r.Rules {
+		cr, err := rule.compile()
+		if err != nil {
+			name := rule.Name
+			if len(name) == 0 {
+				name = strconv.Itoa(i + 1)
+			}
+			return nil, fmt.Errorf("invalid redaction rule %s: %w", name, err)
+		}
+		red.rules = append(red.rules, cr)
+	}
+	return red, nil
+}
+
+func (r *RedactionRule) compile() (redactionRule, error) {
+	cr := redactionRule{replacement: r.Replacement}
+	if len(cr.replacement) == 0 {
+		cr.replacement = DefaultReplacement
+	}
+	switch strings.ToLower(r.Strategy) {
+	case "", RedactReplace:
+	case RedactHash:
+		cr.hash = true
+	default:
+		return cr, fmt.Errorf("unknown strategy %s, expected %s or %s", r.Strategy, RedactReplace, RedactHash)
+	}
+	switch {
+	case len(r.Pattern) > 0 && len(r.Detector) > 0:
+		return cr, errors.New("either a pattern or a detector is expected, not both")
+	case len(r.Pattern) > 0:
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return cr, fmt.Errorf("invalid pattern: %w", err)
+		}
+		cr.detector = &detector{re: re}
+	case len(r.Detector) > 0:
+		d, ok := detectors[strings.ToLower(r.Detector)]
+		if !ok {
+			return cr, fmt.Errorf("unknown detector %s, expected one of %s", r.Detector,
+				strings.Join(Detectors(), ", "))
+		}
+		cr.detector = &d
+	case len(r.Keys) == 0:
+		return cr, errors.New("keys, a pattern or a detector is expected")
+	}
+	for _, k := range r.Keys {
+		p, err := ParsePath(k)
+		if err != nil {
+			return cr, err
+		}
+		cr.keys = append(cr.keys, p)
+		cr.names = append(cr.names, k)
+	}
+	return cr, nil
+}
+
+type redactionRule struct {
+	keys []Path
+	// names are the raw key names, which also stand for top-level keys literally.
+	names       []string
+	detector    *detector
+	hash        bool
+	replacement string
+}
+
+// appliesTo tells whether the rule applies to the field at steps, or with prefix, to
+// the fields within too.
+func (r *redactionRule) appliesTo(steps []string, prefix bool) bool {
+	if len(r.keys) == 0 {
+		return prefix
+	}
+	for i, p := range r.keys {
+		if p.matches(steps, prefix) || (len(steps) > 0 && steps[0] == r.names[i] &&
+			(len(steps) == 1 || prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches tells whether steps are the path p, or with prefix, are within p.
+func (p Path) matches(steps []string, prefix bool) bool {
+	if len(steps) < len(p) || (!prefix && len(steps) > len(p)) {
+		return false
+	}
+	for i, step := range p {
+		if !step.wildcard && step.key != steps[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mask returns what text is replaced with, its digest keyed with hashKey for the hash
+// strategy.
+func (r *redactionRule) mask(text string, hashKey []byte) string {
+	if r.hash {
+		mac := hmac.New(sha256.New, hashKey)
+		mac.Write([]byte(text))
+		return fmt.Sprintf("[hmac:%x]", mac.Sum(nil)[:8])
+	}
+	return r.replacement
+}
+
+var (
+	hashKey     []byte
+	hashKeyOnce sync.Once
+)
+
+// sessionHashKey is the random key of the hash strategy when none is set, the same for
+// the whole session so that digests can be compared across sources.
+func sessionHashKey() []byte {
+	hashKeyOnce.Do(func() {
+		hashKey = make([]byte, 32)
+		_, _ = rand.Read(hashKey)
+	})
+	return hashKey
+}
+
+// Redactor masks the values of records, see Redaction.
+type Redactor struct {
+	rules       []redactionRule
+	allowReveal bool
+	hashKey     []byte
+}
+
+// Redactions is the value of the Redacted pseudo-field of a redacted record, listing
+// the paths of the fields that were, along with the raw record if revealing is allowed.
+// Only the paths are marshalled.
+type Redactions struct {
+	Fields []string
+	raw    map[string]interface{}
+}
+
+func (r *Redactions) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Fields)
+}
+
+// Reveal returns the raw record m was redacted from if it was kept, see
+// Redaction.AllowReveal, or else m.
+func Reveal(m map[string]interface{}) map[string]interface{} {
+	if r, ok := m[Redacted].(*Redactions); ok && r.raw != nil {
+		return r.raw
+	}
+	return m
+}
+
+// AllowReveal tells whether raw records are kept, to be revealed.
+func (r *Redactor) AllowReveal() bool {
+	return r.allowReveal
+}
+
+// Redact returns m with the values the rules apply to masked, along with the Redacted
+// pseudo-field listing their paths. m itself is left untouched, and returned as is if
+// there's nothing to mask.
+func (r *Redactor) Redact(m map[string]interface{}) map[string]interface{} {
+	var fields []string
+	v, changed := r.redact(m, nil, &fields)
+	if !changed {
+		return m
+	}
+	out := v.(map[string]interface{})
+	red := &Redactions{Fields: fields}
+	if r.allowReveal {
+		red.raw = m
+	}
+	out[Redacted] = red
+	return out
+}
+
+// redact masks v, found at steps, returning whether anything was. Maps and arrays are
+// copied on the way rather than changed.
+func (r *Redactor) redact(v interface{}, steps []string, fields *[]string) (interface{}, bool) {
+	if len(steps) > 0 {
+		for i := range r.rules {
+			if rule := &r.rules[i]; rule.detector == nil && rule.appliesTo(steps, false) {
+				*fields = append(*fields, joinPath(steps))
+				return rule.mask(maskedText(v), r.hashKey), true
+			}
+		}
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var out map[string]interface{}
+		for _, k := range keys {
+			if nv, ok := r.redact(val[k], append(steps[:len(steps):len(steps)], k), fields); ok {
+				if out == nil {
+					out = maps.Clone(val)
+				}
+				out[k] = nv
+			}
+		}
+		return out, out != nil
+	case []interface{}:
+		var out []interface{}
+		for i, e := range val {
+			if nv, ok := r.redact(e, append(steps[:len(steps):len(steps)], strconv.Itoa(i)), fields); ok {
+				if out == nil {
+					out = slices.Clone(val)
+				}
+				out[i] = nv
+			}
+		}
+		return out, out != nil
+	case string:
+		if s, ok := r.redactText(val, steps); ok {
+			*fields = append(*fields, joinPath(steps))
+			return s, true
+		}
+	case json.Number:
+		if s, ok := r.redactText(string(val), steps); ok {
+			*fields = append(*fields, joinPath(steps))
+			return s, true
+		}
+	}
+	return v, false
+}
+
+// redactText masks the matches of the patterns applying to the field at steps.
+func (r *Redactor) redactText(text string, steps []string) (string, bool) {
+	changed := false
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if rule.detector == nil || !rule.appliesTo(steps, true) {
+			continue
+		}
+		text = rule.detector.re.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.detector.valid != nil && !rule.detector.valid(match) {
+				return match
+			}
+			changed = true
+			return rule.mask(match, r.hashKey)
+		})
+	}
+	return text, changed
+}
+
+// maskedText is the text of v to be masked, e.g. hashed.
+func maskedText(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return string(val)
+	}
+	return formatValue(v)
+}
+
+// joinPath joins steps into the path of a key, escaping slashes and backslashes.
+func joinPath(steps []string) string {
+	escaped := make([]string, len(steps))
+	for i, s := range steps {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(s)
+	}
+	return strings.Join(escaped, "/")
+}
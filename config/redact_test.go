@@ -0,0 +1,249 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      []RedactionRule
+		hashKey    string
+		given      map[string]interface{}
+		expected   map[string]interface{}
+		wantFields []string
+	}{
+		{
+			name:       "Key",
+			rules:      []RedactionRule{{Keys: []string{"user/email"}}},
+			given:      map[string]interface{}{"user": map[string]interface{}{"email": "a@b.io", "id": "1"}},
+			expected:   map[string]interface{}{"user": map[string]interface{}{"email": DefaultReplacement, "id": "1"}},
+			wantFields: []string{"user/email"},
+		},
+		{
+			name:       "Key with slash",
+			rules:      []RedactionRule{{Keys: []string{`"logging.googleapis.com/trace"`}, Replacement: "***"}},
+			given:      map[string]interface{}{"logging.googleapis.com/trace": "abc"},
+			expected:   map[string]interface{}{"logging.googleapis.com/trace": "***"},
+			wantFields: []string{`logging.googleapis.com\/trace`},
+		},
+		{
+			name:       "Key wildcard",
+			rules:      []RedactionRule{{Keys: []string{"users/*/token"}}},
+			given:      map[string]interface{}{"users": []interface{}{map[string]interface{}{"token": "x"}, map[string]interface{}{"token": "y"}}},
+			expected:   map[string]interface{}{"users": []interface{}{map[string]interface{}{"token": DefaultReplacement}, map[string]interface{}{"token": DefaultReplacement}}},
+			wantFields: []string{"users/0/token", "users/1/token"},
+		},
+		{
+			name:       "Whole object",
+			rules:      []RedactionRule{{Keys: []string{"auth"}}},
+			given:      map[string]interface{}{"auth": map[string]interface{}{"user": "a"}, "n": json.Number("1")},
+			expected:   map[string]interface{}{"auth": DefaultReplacement, "n": json.Number("1")},
+			wantFields: []string{"auth"},
+		},
+		{
+			name:       "Detector anywhere",
+			rules:      []RedactionRule{{Detector: "email"}},
+			given:      map[string]interface{}{"message": "sent to a.b@example.com and c@d.org", "to": []interface{}{"x@y.com"}},
+			expected:   map[string]interface{}{"message": "sent to [REDACTED] and [REDACTED]", "to": []interface{}{"[REDACTED]"}},
+			wantFields: []string{"message", "to/0"},
+		},
+		{
+			name:       "Detector within keys",
+			rules:      []RedactionRule{{Keys: []string{"request"}, Detector: "bearer"}},
+			given:      map[string]interface{}{"request": map[string]interface{}{"headers": "Authorization: Bearer abc.def"}, "message": "Bearer abc.def"},
+			expected:   map[string]interface{}{"request": map[string]interface{}{"headers": "Authorization: [REDACTED]"}, "message": "Bearer abc.def"},
+			wantFields: []string{"request/headers"},
+		},
+		{
+			name:       "Card passing Luhn",
+			rules:      []RedactionRule{{Detector: "card"}},
+			given:      map[string]interface{}{"a": "paid with 4111 1111 1111 1111", "b": "order 4111111111111112", "c": json.Number("4111111111111111")},
+			expected:   map[string]interface{}{"a": "paid with [REDACTED]", "b": "order 4111111111111112", "c": DefaultReplacement},
+			wantFields: []string{"a", "c"},
+		},
+		{
+			name:       "Pattern",
+			rules:      []RedactionRule{{Pattern: `secret=\w+`, Replacement: "secret=?"}},
+			given:      map[string]interface{}{"message": "login secret=hunter2 ok"},
+			expected:   map[string]interface{}{"message": "login secret=? ok"},
+			wantFields: []string{"message"},
+		},
+		{
+			name:       "Hash",
+			rules:      []RedactionRule{{Keys: []string{"user"}, Strategy: RedactHash}},
+			hashKey:    "secret",
+			given:      map[string]interface{}{"user": "alice"},
+			expected:   map[string]interface{}{"user": "[hmac:4360c67bc8102511]"},
+			wantFields: []string{"user"},
+		},
+		{
+			name:     "Nothing to mask",
+			rules:    []RedactionRule{{Keys: []string{"user"}}, {Detector: "email"}},
+			given:    map[string]interface{}{"message": "hello"},
+			expected: map[string]interface{}{"message": "hello"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := (&Redaction{Rules: tt.rules, HashKey: tt.hashKey}).compile()
+			assert.NoError(t, err)
+			given, err := json.Marshal(tt.given)
+			assert.NoError(t, err)
+			got := r.Redact(tt.given)
+			red, ok := got[Redacted].(*Redactions)
+			if len(tt.wantFields) == 0 {
+				assert.False(t, ok)
+			} else {
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantFields, red.Fields)
+				delete(got, Redacted)
+			}
+			assert.Equal(t, tt.expected, got)
+			// The record redacted from is left untouched.
+			after, err := json.Marshal(tt.given)
+			assert.NoError(t, err)
+			assert.JSONEq(t, string(given), string(after))
+		})
+	}
+}
+
+func TestRedactor_HashKey(t *testing.T) {
+	rules := []RedactionRule{{Keys: []string{"user"}, Strategy: RedactHash}}
+	hash := func(key string) string {
+		r, err := (&Redaction{Rules: rules, HashKey: key}).compile()
+		assert.NoError(t, err)
+		return r.Redact(map[string]interface{}{"user": "alice"})["user"].(string)
+	}
+	// Without a key, digests match within the session but aren't plain SHA-256 ones.
+	assert.Equal(t, hash(""), hash(""))
+	assert.NotEqual(t, "[hmac:2bd806c97f0e00af]", hash(""))
+	assert.NotEqual(t, hash("secret"), hash("other"))
+
+	c := &Config{Redaction: &Redaction{HashKey: "template"}}
+	c.AddRedaction(&Redaction{HashKey: "user"})
+	assert.Equal(t, "template", c.Redaction.HashKey)
+}
+
+func TestReveal(t *testing.T) {
+	raw := map[string]interface{}{"email": "a@b.io"}
+	rules := []RedactionRule{{Keys: []string{"email"}}}
+
+	r, err := (&Redaction{Rules: rules}).compile()
+	assert.NoError(t, err)
+	assert.False(t, r.AllowReveal())
+	redacted := r.Redact(raw)
+	assert.Equal(t, redacted, Reveal(redacted))
+	b, err := json.Marshal(redacted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"email":"[REDACTED]","$_redacted":["email"]}`, string(b))
+
+	r, err = (&Redaction{Rules: rules, AllowReveal: true}).compile()
+	assert.NoError(t, err)
+	assert.True(t, r.AllowReveal())
+	assert.Equal(t, raw, Reveal(r.Redact(raw)))
+}
+
+func TestRedaction_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    RedactionRule
+		wantsErr bool
+	}{
+		{name: "Keys", given: RedactionRule{Keys: []string{"a/b"}}},
+		{name: "Detector", given: RedactionRule{Detector: "jwt", Strategy: RedactHash}},
+		{name: "Pattern", given: RedactionRule{Pattern: `\d+`, Strategy: RedactReplace}},
+		{name: "Nothing to match", given: RedactionRule{Name: "empty"}, wantsErr: true},
+		{name: "Pattern and detector", given: RedactionRule{Pattern: `\d+`, Detector: "email"}, wantsErr: true},
+		{name: "Bad pattern", given: RedactionRule{Pattern: `(`}, wantsErr: true},
+		{name: "Unknown detector", given: RedactionRule{Detector: "ssn"}, wantsErr: true},
+		{name: "Unknown strategy", given: RedactionRule{Keys: []string{"a"}, Strategy: "drop"}, wantsErr: true},
+		{name: "Bad key", given: RedactionRule{Keys: []string{`a/"b`}}, wantsErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Redaction{Rules: []RedactionRule{tt.given}}).Validate()
+			assert.Equal(t, tt.wantsErr, err != nil, err)
+		})
+	}
+}
+
+func TestConfig_Redactor(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	r, err := (&Config{}).Redactor()
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	assert.NoError(t, os.MkdirAll(path.Join(home, parentPath), os.ModePerm))
+	file := path.Join(home, parentPath, redactFile)
+	assert.NoError(t, os.WriteFile(file, []byte("rules:\n  - detector: email\nallow-reveal: true\n"), 0644))
+	c := &Config{Redaction: &Redaction{Rules: []RedactionRule{{Keys: []string{"token"}}}}}
+	r, err = c.Redactor()
+	assert.NoError(t, err)
+	assert.True(t, r.AllowReveal())
+	got := r.Redact(map[string]interface{}{"token": "t", "to": "a@b.io"})
+	assert.Equal(t, DefaultReplacement, got["token"])
+	assert.Equal(t, DefaultReplacement, got["to"])
+	// The rules of the user aren't added to the config itself.
+	assert.Len(t, c.Redaction.Rules, 1)
+
+	assert.NoError(t, os.WriteFile(file, []byte("rules:\n  - detector: ssn\n"), 0644))
+	_, err = c.Redactor()
+	assert.Error(t, err)
+}
+
+func TestMakeConfig_Redaction(t *testing.T) {
+	file := t.TempDir() + "/template.yaml"
+	assert.NoError(t, os.WriteFile(file, []byte(`keys:
+  - name: message
+    type: string
+redact:
+  rules:
+    - name: emails
+      detector: email
+    - keys: [user/id]
+      strategy: hash
+  allow-reveal: true
+`), 0644))
+	c, err := MakeConfig(file)
+	assert.NoError(t, err)
+	assert.Equal(t, &Redaction{
+		Rules: []RedactionRule{
+			{Name: "emails", Detector: "email"},
+			{Keys: []string{"user/id"}, Strategy: RedactHash},
+		},
+		AllowReveal: true,
+	}, c.Redaction)
+
+	assert.NoError(t, os.WriteFile(file, []byte("keys:\n  - name: message\n    type: string\nredact:\n  rules:\n    - pattern: (\n"), 0644))
+	_, err = MakeConfig(file)
+	assert.Error(t, err)
+}
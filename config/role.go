@@ -0,0 +1,201 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role tells what a key stands for, whatever its name, so that features can find the
+// level or the timestamp of a record on any schema.
+type Role string
+
+const (
+	RoleNone      Role = ""
+	RoleTimestamp Role = "timestamp"
+	RoleLevel     Role = "level"
+	RoleMessage   Role = "message"
+	RoleTrace     Role = "trace"
+	RoleSpan      Role = "span"
+)
+
+const (
+	parentPath = ".loggo"
+	rolesFile  = "roles.yaml"
+)
+
+// Roles lists the known roles.
+func Roles() []Role {
+	return []Role{RoleTimestamp, RoleLevel, RoleMessage, RoleTrace, RoleSpan}
+}
+
+// DefaultRoleAliases are the key names each role goes by, in order of preference.
+var DefaultRoleAliases = map[Role][]string{
+	RoleTimestamp: {"timestamp", "time", "@timestamp", "ts", "@t", "datetime", "eventTime", "$_time"},
+	RoleLevel:     {"level", "severity", "lvl", "loglevel", "levelname", "log.level", "log/level", "@l"},
+	RoleMessage:   {"message", "msg", "@m", "@mt", "textPayload", "jsonPayload/message", "log", "body"},
+	RoleTrace: {"traceId", "trace_id", "traceID", "trace", "trace.id", "trace/id", "dd.trace_id", "@tr",
+		"logging.googleapis.com/trace"},
+	RoleSpan: {"spanId", "span_id", "spanID", "span", "span.id", "span/id", "dd.span_id", "@sp",
+		"logging.googleapis.com/spanId"},
+}
+
+var (
+	// baseRoleAliases are the user's aliases followed by the default ones, loaded once.
+	baseRoleAliases     map[Role][]string
+	baseRoleAliasesOnce sync.Once
+)
+
+// LoadRoleAliases reads the aliases defined by the user in ~/.loggo/roles.yaml, listing
+// the extra key names of each role, e.g. level: [sev, prio]. It returns nil if there's
+// no such file.
+func LoadRoleAliases() (map[Role][]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path.Join(home, parentPath, rolesFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	aliases := make(map[Role][]string)
+	if err := yaml.Unmarshal(b, &aliases); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", rolesFile, err)
+	}
+	for role := range aliases {
+		if !role.Valid() {
+			return nil, fmt.Errorf("invalid %s: unknown role %s", rolesFile, role)
+		}
+	}
+	return aliases, nil
+}
+
+// Valid tells whether r is a known role, or none.
+func (r Role) Valid() bool {
+	if r == RoleNone {
+		return true
+	}
+	for _, known := range Roles() {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAliases returns the alias table: the aliases of the template come first, then
+// the user's, then the default ones. The table is shared, so it must not be modified,
+// and it's merged the first time it's asked for, so Roles must be set by then.
+func (c *Config) RoleAliases() map[Role][]string {
+	baseRoleAliasesOnce.Do(func() {
+		// A broken file is no reason not to show the logs.
+		userRoleAliases, _ := LoadRoleAliases()
+		baseRoleAliases = mergeRoleAliases(userRoleAliases, DefaultRoleAliases)
+	})
+	if c == nil {
+		return baseRoleAliases
+	}
+	c.roleAliasesOnce.Do(func() {
+		c.roleAliases = baseRoleAliases
+		if len(c.Roles) > 0 {
+			c.roleAliases = mergeRoleAliases(c.Roles, baseRoleAliases)
+		}
+	})
+	return c.roleAliases
+}
+
+// mergeRoleAliases returns the aliases of each role in first, followed by the ones in
+// then.
+func mergeRoleAliases(first, then map[Role][]string) map[Role][]string {
+	aliases := make(map[Role][]string)
+	for _, role := range Roles() {
+		names := append([]string(nil), first[role]...)
+		// Clipped, so that appending to the shared aliases never writes into them.
+		aliases[role] = slices.Clip(append(names, then[role]...))
+	}
+	return aliases
+}
+
+// RoleOf tells the role of k, either set explicitly or told by its name.
+func (c *Config) RoleOf(k *Key) Role {
+	if k.Role != RoleNone {
+		return k.Role
+	}
+	return roleOfName(c.RoleAliases(), k.Name)
+}
+
+func roleOfName(aliases map[Role][]string, name string) Role {
+	for _, role := range Roles() {
+		for _, alias := range aliases[role] {
+			if alias == name {
+				return role
+			}
+		}
+	}
+	return RoleNone
+}
+
+// KeyFor returns the key playing role, preferring keys whose role is explicit, or nil
+// if there's none.
+func (c *Config) KeyFor(role Role) *Key {
+	for i := range c.Keys {
+		if c.Keys[i].Role == role {
+			return &c.Keys[i]
+		}
+	}
+	aliases := c.RoleAliases()[role]
+	for _, alias := range aliases {
+		for i := range c.Keys {
+			if c.Keys[i].Role == RoleNone && c.Keys[i].Name == alias {
+				return &c.Keys[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ValueOf returns the value playing role in m, as found by the key for the role or
+// else by the first alias of the role found in m.
+func (c *Config) ValueOf(m map[string]interface{}, role Role) (string, bool) {
+	if k := c.KeyFor(role); k != nil {
+		v := k.ExtractValue(m)
+		return v, len(v) > 0
+	}
+	for _, alias := range c.RoleAliases()[role] {
+		// Aliases are key names, so they may be paths, e.g. log/level for
+		// {"log": {"level": "info"}}.
+		k := Key{Name: alias, Type: TypeString}
+		if found, _ := k.lookup(m); len(found) > 0 {
+			return k.ExtractValue(m), true
+		}
+	}
+	return "", false
+}
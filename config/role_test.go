@@ -0,0 +1,161 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRoleAliases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	aliases, err := LoadRoleAliases()
+	assert.NoError(t, err)
+	assert.Nil(t, aliases)
+
+	assert.NoError(t, os.MkdirAll(path.Join(home, parentPath), os.ModePerm))
+	file := path.Join(home, parentPath, rolesFile)
+	assert.NoError(t, os.WriteFile(file, []byte("level: [sev, prio]\ntrace: [x-trace]\n"), 0644))
+	aliases, err = LoadRoleAliases()
+	assert.NoError(t, err)
+	assert.Equal(t, map[Role][]string{RoleLevel: {"sev", "prio"}, RoleTrace: {"x-trace"}}, aliases)
+
+	assert.NoError(t, os.WriteFile(file, []byte("color: [tint]\n"), 0644))
+	_, err = LoadRoleAliases()
+	assert.Error(t, err)
+}
+
+func TestConfig_Roles(t *testing.T) {
+	c := &Config{
+		Keys: []Key{
+			{Name: "ts", Type: TypeDateTime},
+			{Name: "lvl", Type: TypeString},
+			{Name: "sev", Type: TypeString},
+			{Name: "text", Type: TypeString, Role: RoleMessage},
+			{Name: "msg", Type: TypeString},
+			{Name: "logging.googleapis.com/trace", Type: TypeString},
+		},
+		Roles: map[Role][]string{RoleLevel: {"sev"}},
+	}
+	tests := []struct {
+		role      Role
+		wantsKey  string
+		wantsRole Role
+	}{
+		{role: RoleTimestamp, wantsKey: "ts"},
+		// Aliases of the template come first
+		{role: RoleLevel, wantsKey: "sev"},
+		// Explicit roles win over aliases
+		{role: RoleMessage, wantsKey: "text"},
+		{role: RoleTrace, wantsKey: "logging.googleapis.com/trace"},
+		{role: RoleSpan},
+	}
+	for _, test := range tests {
+		t.Run(string(test.role), func(t *testing.T) {
+			k := c.KeyFor(test.role)
+			if len(test.wantsKey) == 0 {
+				assert.Nil(t, k)
+				return
+			}
+			if assert.NotNil(t, k) {
+				assert.Equal(t, test.wantsKey, k.Name)
+				assert.Equal(t, test.role, c.RoleOf(k))
+			}
+		})
+	}
+	assert.Equal(t, RoleNone, c.RoleOf(&Key{Name: "foo"}))
+
+	v, ok := c.ValueOf(map[string]interface{}{"sev": "WARN", "lvl": "INFO"}, RoleLevel)
+	assert.True(t, ok)
+	assert.Equal(t, "WARN", v)
+	v, ok = (&Config{}).ValueOf(map[string]interface{}{"@l": "Warning"}, RoleLevel)
+	assert.True(t, ok)
+	assert.Equal(t, "Warning", v)
+	v, ok = (&Config{}).ValueOf(map[string]interface{}{"log": map[string]interface{}{"level": "error"}}, RoleLevel)
+	assert.True(t, ok)
+	assert.Equal(t, "error", v)
+	_, ok = (&Config{}).ValueOf(map[string]interface{}{"foo": "bar"}, RoleLevel)
+	assert.False(t, ok)
+
+	km := c.KeyMap()
+	assert.Equal(t, RoleLevel, km["sev"].Role)
+	assert.Equal(t, RoleMessage, km["msg"].Role)
+	assert.Equal(t, RoleNone, c.Keys[2].Role)
+}
+
+func TestConfig_RoleAliases(t *testing.T) {
+	c := &Config{}
+	assert.Zero(t, testing.AllocsPerRun(10, func() { c.RoleAliases() }))
+
+	// Merged once, however often asked for.
+	c = &Config{Roles: map[Role][]string{RoleLevel: {"sev"}}}
+	aliases := c.RoleAliases()
+	assert.Zero(t, testing.AllocsPerRun(10, func() { c.RoleAliases() }))
+	assert.Equal(t, "sev", aliases[RoleLevel][0])
+	assert.Equal(t, DefaultRoleAliases[RoleLevel][0], aliases[RoleLevel][1])
+	// Appending to the aliases leaves the shared ones alone.
+	base := (*Config)(nil).RoleAliases()[RoleLevel]
+	_ = append(base, "extra")
+	assert.Equal(t, len(base), len((*Config)(nil).RoleAliases()[RoleLevel]))
+	assert.Equal(t, cap(base), len(base))
+}
+
+func TestMakeConfig_Roles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	assert.NoError(t, os.WriteFile(good, []byte("roles:\n  level: [sev]\nkeys:\n  - name: text\n    type: string\n    role: message\n"), 0644))
+	c, err := MakeConfig(good)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleMessage, c.Keys[0].Role)
+	assert.Equal(t, []string{"sev"}, c.Roles[RoleLevel])
+
+	bad := filepath.Join(dir, "bad.yaml")
+	assert.NoError(t, os.WriteFile(bad, []byte("keys:\n  - name: text\n    type: string\n    role: colour\n"), 0644))
+	_, err = MakeConfig(bad)
+	assert.Error(t, err)
+}
+
+func TestMakeConfigFromSample_Roles(t *testing.T) {
+	c, _ := MakeConfigFromSample([]map[string]interface{}{
+		{"msg": "hi", "lvl": "info", "span_id": "b", "ts": "2026-10-01T12:00:00Z", "trace_id": "a", "user": "x"},
+	})
+	var names []string
+	var roles []Role
+	for _, k := range c.Keys {
+		names = append(names, k.Name)
+		roles = append(roles, k.Role)
+	}
+	assert.Equal(t, []string{"ts", "lvl", "trace_id", "span_id", "msg", "user"}, names)
+	assert.Equal(t, []Role{RoleTimestamp, RoleLevel, RoleTrace, RoleSpan, RoleMessage, RoleNone}, roles)
+	assert.Equal(t, Type(TypeDateTime), c.Keys[0].Type)
+	assert.NotEmpty(t, c.Keys[1].ColorWhen)
+	// Numeric trace IDs aren't taken for epochs.
+	assert.Equal(t, Type(TypeString), c.Keys[2].Type)
+	assert.Equal(t, "1790856000", c.Keys[2].FormatTime("1790856000", time.UTC))
+}
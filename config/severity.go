@@ -0,0 +1,159 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity is a level on the canonical scale every log level maps onto, whatever the
+// scheme of the logs, e.g. 40 for pino, 4 for syslog or Warning for .NET all being
+// SeverityWarn. Higher is more severe.
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityTrace
+	SeverityDebug
+	SeverityInfo
+	SeverityNotice
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+// Severities lists the canonical levels, most severe first.
+func Severities() []Severity {
+	return []Severity{SeverityFatal, SeverityError, SeverityWarn, SeverityNotice, SeverityInfo,
+		SeverityDebug, SeverityTrace}
+}
+
+// severityAliases are the names each level goes by, in lower case.
+var severityAliases = map[Severity][]string{
+	SeverityTrace:  {"trace", "verbose", "finest", "finer", "t"},
+	SeverityDebug:  {"debug", "dbg", "fine", "d"},
+	SeverityInfo:   {"info", "information", "informational", "i"},
+	SeverityNotice: {"notice", "n"},
+	SeverityWarn:   {"warn", "warning", "w"},
+	SeverityError:  {"error", "err", "severe", "e"},
+	SeverityFatal:  {"fatal", "critical", "crit", "alert", "emergency", "emerg", "panic", "dpanic", "f"},
+}
+
+var severityNames = func() map[string]Severity {
+	names := make(map[string]Severity)
+	for s, aliases := range severityAliases {
+		for _, alias := range aliases {
+			names[alias] = s
+		}
+	}
+	return names
+}()
+
+// severityOffset matches slog levels in between the named ones, e.g. INFO+2.
+var severityOffset = regexp.MustCompile(`^([a-z]+)[+-]\d+$`)
+
+// ParseSeverity maps a level onto the canonical scale. Level names are recognised
+// whatever their case, including abbreviations and vendor-specific ones such as
+// Information, crit or dpanic. Numbers from 0 to 7 are taken as syslog severities,
+// and numbers from 10 up as pino and bunyan levels. It returns SeverityNone if value
+// isn't a level.
+func ParseSeverity(value string) Severity {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		return severityOfNumber(n)
+	}
+	if m := severityOffset.FindStringSubmatch(v); m != nil {
+		v = m[1]
+	}
+	return severityNames[v]
+}
+
+func severityOfNumber(n float64) Severity {
+	switch {
+	case n >= 0 && n <= 7 && n == float64(int(n)):
+		// Syslog, from emergency down to debug
+		return []Severity{SeverityFatal, SeverityFatal, SeverityFatal, SeverityError,
+			SeverityWarn, SeverityNotice, SeverityInfo, SeverityDebug}[int(n)]
+	case n < 10:
+		return SeverityNone
+	case n <= 10:
+		return SeverityTrace
+	case n <= 20:
+		return SeverityDebug
+	case n <= 30:
+		return SeverityInfo
+	case n <= 40:
+		return SeverityWarn
+	case n <= 50:
+		return SeverityError
+	}
+	return SeverityFatal
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityTrace:
+		return "TRACE"
+	case SeverityDebug:
+		return "DEBUG"
+	case SeverityInfo:
+		return "INFO"
+	case SeverityNotice:
+		return "NOTICE"
+	case SeverityWarn:
+		return "WARN"
+	case SeverityError:
+		return "ERROR"
+	case SeverityFatal:
+		return "FATAL"
+	}
+	return ""
+}
+
+func (s Severity) GetColorName() string {
+	switch s {
+	case SeverityTrace:
+		return "gray"
+	case SeverityDebug:
+		return "blue"
+	case SeverityInfo:
+		return "green"
+	case SeverityNotice:
+		return "teal"
+	case SeverityWarn:
+		return "orange"
+	case SeverityError, SeverityFatal:
+		return "red"
+	}
+	return "yellow"
+}
+
+// SeverityOf returns the canonical level of m, as found by the key with the level role.
+func (c *Config) SeverityOf(m map[string]interface{}) Severity {
+	if v, ok := c.ValueOf(m, RoleLevel); ok {
+		return ParseSeverity(v)
+	}
+	return SeverityNone
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		value string
+		wants Severity
+	}{
+		{value: "10", wants: SeverityTrace},
+		{value: "20", wants: SeverityDebug},
+		{value: "30", wants: SeverityInfo},
+		{value: "35", wants: SeverityWarn},
+		{value: "40", wants: SeverityWarn},
+		{value: "50", wants: SeverityError},
+		{value: "60", wants: SeverityFatal},
+		{value: "0", wants: SeverityFatal},
+		{value: "3", wants: SeverityError},
+		{value: "4", wants: SeverityWarn},
+		{value: "5", wants: SeverityNotice},
+		{value: "6", wants: SeverityInfo},
+		{value: "7", wants: SeverityDebug},
+		{value: "8"},
+		{value: "-1"},
+		{value: "Verbose", wants: SeverityTrace},
+		{value: "debug", wants: SeverityDebug},
+		{value: "Information", wants: SeverityInfo},
+		{value: "Informational", wants: SeverityInfo},
+		{value: "INFO+2", wants: SeverityInfo},
+		{value: "WARN-4", wants: SeverityWarn},
+		{value: "Warning", wants: SeverityWarn},
+		{value: " err ", wants: SeverityError},
+		{value: "SEVERE", wants: SeverityError},
+		{value: "crit", wants: SeverityFatal},
+		{value: "EMERGENCY", wants: SeverityFatal},
+		{value: "dpanic", wants: SeverityFatal},
+		{value: "DEFAULT"},
+		{value: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.wants, ParseSeverity(test.value))
+		})
+	}
+}
+
+func TestConfig_SeverityOf(t *testing.T) {
+	c := &Config{Keys: []Key{{Name: "sev", Type: TypeNumber, Role: RoleLevel}}}
+	assert.Equal(t, SeverityError, c.SeverityOf(map[string]interface{}{"sev": 50.0}))
+	assert.Equal(t, SeverityNone, c.SeverityOf(map[string]interface{}{"level": "info"}))
+
+	// Without a key, the level is found by its aliases.
+	c = &Config{}
+	assert.Equal(t, SeverityWarn, c.SeverityOf(map[string]interface{}{"@l": "Warning"}))
+	assert.Equal(t, SeverityNone, c.SeverityOf(map[string]interface{}{"msg": "hi"}))
+}
+
+func TestSeverity_String(t *testing.T) {
+	for _, s := range Severities() {
+		assert.Equal(t, s, ParseSeverity(s.String()))
+	}
+	assert.Empty(t, SeverityNone.String())
+}
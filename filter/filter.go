@@ -23,7 +23,9 @@ THE SOFTWARE.
 package filter
 
 import (
+	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -213,8 +215,8 @@ func (f *equals) Apply(value string, key map[string]*config.Key) (bool, error) {
 	case config.TypeString:
 		return f.KeyExpression[0] == value, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
-			return number == expression, nil
+		return f.parseNumberAndCheck(value, func(cmp int) (bool, error) {
+			return cmp == 0, nil
 		})
 	case config.TypeBool:
 		return f.parseBoolAndCheck(value, func(value, expression bool) (bool, error) {
@@ -260,8 +262,8 @@ func (f *equalsIgnoreCase) Apply(value string, key map[string]*config.Key) (bool
 	case config.TypeString:
 		return strings.ToLower(f.KeyExpression[0]) == strings.ToLower(value), nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
-			return number == expression, nil
+		return f.parseNumberAndCheck(value, func(cmp int) (bool, error) {
+			return cmp == 0, nil
 		})
 	case config.TypeBool:
 		return f.parseBoolAndCheck(value, func(value, expression bool) (bool, error) {
@@ -300,6 +302,11 @@ type lowerThan struct {
 }
 
 func (f *lowerThan) Apply(value string, key map[string]*config.Key) (bool, error) {
+	if r, ok := f.parseSeverityAndCheck(value, key, func(value, expression config.Severity) bool {
+		return value < expression
+	}); ok {
+		return r, nil
+	}
 	var tp config.Type = config.TypeString
 	var k *config.Key
 	if v, ok := key[f.KeyName]; ok {
@@ -310,8 +317,8 @@ func (f *lowerThan) Apply(value string, key map[string]*config.Key) (bool, error
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) < 0, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
-			return number < expression, nil
+		return f.parseNumberAndCheck(value, func(cmp int) (bool, error) {
+			return cmp < 0, nil
 		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
@@ -326,6 +333,11 @@ type greaterThan struct {
 }
 
 func (f *greaterThan) Apply(value string, key map[string]*config.Key) (bool, error) {
+	if r, ok := f.parseSeverityAndCheck(value, key, func(value, expression config.Severity) bool {
+		return value > expression
+	}); ok {
+		return r, nil
+	}
 	var tp config.Type = config.TypeString
 	var k *config.Key
 	if v, ok := key[f.KeyName]; ok {
@@ -336,8 +348,8 @@ func (f *greaterThan) Apply(value string, key map[string]*config.Key) (bool, err
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) > 0, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
-			return number > expression, nil
+		return f.parseNumberAndCheck(value, func(cmp int) (bool, error) {
+			return cmp > 0, nil
 		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
@@ -352,6 +364,11 @@ type lowerOrEqualThan struct {
 }
 
 func (f *lowerOrEqualThan) Apply(value string, key map[string]*config.Key) (bool, error) {
+	if r, ok := f.parseSeverityAndCheck(value, key, func(value, expression config.Severity) bool {
+		return value <= expression
+	}); ok {
+		return r, nil
+	}
 	var tp config.Type = config.TypeString
 	var k *config.Key
 	if v, ok := key[f.KeyName]; ok {
@@ -362,8 +379,8 @@ func (f *lowerOrEqualThan) Apply(value string, key map[string]*config.Key) (bool
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) <= 0, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
-			return number <= expression, nil
+		return f.parseNumberAndCheck(value, func(cmp int) (bool, error) {
+			return cmp <= 0, nil
 		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
@@ -378,6 +395,11 @@ type greaterOrEqualThan struct {
 }
 
 func (f *greaterOrEqualThan) Apply(value string, key map[string]*config.Key) (bool, error) {
+	if r, ok := f.parseSeverityAndCheck(value, key, func(value, expression config.Severity) bool {
+		return value >= expression
+	}); ok {
+		return r, nil
+	}
 	var tp config.Type = config.TypeString
 	var k *config.Key
 	if v, ok := key[f.KeyName]; ok {
@@ -388,8 +410,8 @@ func (f *greaterOrEqualThan) Apply(value string, key map[string]*config.Key) (bo
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) >= 0, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression float64) (bool, error) {
-			return number >= expression, nil
+		return f.parseNumberAndCheck(value, func(cmp int) (bool, error) {
+			return cmp >= 0, nil
 		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression time.Time) (bool, error) {
@@ -404,6 +426,11 @@ type between struct {
 }
 
 func (f *between) Apply(value string, key map[string]*config.Key) (bool, error) {
+	if r, ok := f.parseSeverityAndCheck(value, key, func(value, expression, expression2 config.Severity) bool {
+		return value > expression && value < expression2
+	}); ok {
+		return r, nil
+	}
 	var tp config.Type = config.TypeString
 	var k *config.Key
 	if v, ok := key[f.KeyName]; ok {
@@ -414,8 +441,8 @@ func (f *between) Apply(value string, key map[string]*config.Key) (bool, error)
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) > 0 && strings.Compare(value, f.KeyExpression[1]) < 0, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression, expression2 float64) (bool, error) {
-			return number > expression && number < expression2, nil
+		return f.parseNumberAndCheck(value, func(cmp, cmp2 int) (bool, error) {
+			return cmp > 0 && cmp2 < 0, nil
 		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression, expression2 time.Time) (bool, error) {
@@ -430,6 +457,11 @@ type betweenInclusive struct {
 }
 
 func (f *betweenInclusive) Apply(value string, key map[string]*config.Key) (bool, error) {
+	if r, ok := f.parseSeverityAndCheck(value, key, func(value, expression, expression2 config.Severity) bool {
+		return value >= expression && value <= expression2
+	}); ok {
+		return r, nil
+	}
 	var tp config.Type = config.TypeString
 	var k *config.Key
 	if v, ok := key[f.KeyName]; ok {
@@ -440,8 +472,8 @@ func (f *betweenInclusive) Apply(value string, key map[string]*config.Key) (bool
 	case config.TypeString:
 		return strings.Compare(value, f.KeyExpression[0]) >= 0 && strings.Compare(value, f.KeyExpression[1]) <= 0, nil
 	case config.TypeNumber:
-		return f.parseNumberAndCheck(value, func(number, expression, expression2 float64) (bool, error) {
-			return number >= expression && number <= expression2, nil
+		return f.parseNumberAndCheck(value, func(cmp, cmp2 int) (bool, error) {
+			return cmp >= 0 && cmp2 <= 0, nil
 		})
 	case config.TypeDateTime:
 		return f.parseDateTimeAndCheck(value, k, func(value, expression, expression2 time.Time) (bool, error) {
@@ -452,43 +484,104 @@ func (f *betweenInclusive) Apply(value string, key map[string]*config.Key) (bool
 	return false, nil
 }
 
-func (p *Predicate) parseNumberAndCheck(value string, check func(number, expression float64) (bool, error)) (bool, error) {
-	var n, e float64
+// parseNumberAndCheck compares the value to the expression as numbers, checking how
+// the value compares to it, see number.cmp.
+func (p *Predicate) parseNumberAndCheck(value string, check func(cmp int) (bool, error)) (bool, error) {
+	var n, e number
 	var err error
 	tv := strings.TrimSpace(value)
 	if len(tv) == 0 {
 		value = "0"
 	}
-	n, err = strconv.ParseFloat(value, 64)
+	n, err = parseNumber(value)
 	if err == nil {
-		e, err = strconv.ParseFloat(p.KeyExpression[0], 64)
+		e, err = parseNumber(p.KeyExpression[0])
 		if err == nil {
-			return check(n, e)
+			return check(n.cmp(e))
 		}
 	}
 	return false, err
 }
 
-func (f *between) parseNumberAndCheck(value string, check func(number, expression, expression2 float64) (bool, error)) (bool, error) {
-	var v, e, e2 float64
+func (f *between) parseNumberAndCheck(value string, check func(cmp, cmp2 int) (bool, error)) (bool, error) {
+	var v, e, e2 number
 	var err error
 	tv := strings.TrimSpace(value)
 	if len(tv) == 0 {
 		value = "0"
 	}
-	v, err = strconv.ParseFloat(value, 64)
+	v, err = parseNumber(value)
 	if err == nil {
-		e, err = strconv.ParseFloat(f.KeyExpression[0], 64)
+		e, err = parseNumber(f.KeyExpression[0])
 		if err == nil {
-			e2, err = strconv.ParseFloat(f.KeyExpression[1], 64)
+			e2, err = parseNumber(f.KeyExpression[1])
 			if err == nil {
-				return check(v, e, e2)
+				return check(v.cmp(e), v.cmp(e2))
 			}
 		}
 	}
 	return false, err
 }
 
+// parseSeverityAndCheck compares levels by their severity when the key holds levels
+// and the expression is a canonical level, e.g. level >= WARN, whatever the scheme of
+// the logged levels. It returns false as ok otherwise, for the value to be compared
+// as it is.
+func (p *Predicate) parseSeverityAndCheck(value string, key map[string]*config.Key,
+	check func(value, expression config.Severity) bool) (bool, bool) {
+	if !isLevelKey(p.KeyName, key) {
+		return false, false
+	}
+	e, ok := parseCanonicalSeverity(p.KeyExpression[0])
+	if !ok {
+		return false, false
+	}
+	v := config.ParseSeverity(value)
+	if v == config.SeverityNone {
+		return false, false
+	}
+	return check(v, e), true
+}
+
+func (f *between) parseSeverityAndCheck(value string, key map[string]*config.Key,
+	check func(value, expression, expression2 config.Severity) bool) (bool, bool) {
+	if !isLevelKey(f.KeyName, key) {
+		return false, false
+	}
+	e, ok := parseCanonicalSeverity(f.KeyExpression[0])
+	if !ok {
+		return false, false
+	}
+	e2, ok := parseCanonicalSeverity(f.KeyExpression[1])
+	if !ok {
+		return false, false
+	}
+	v := config.ParseSeverity(value)
+	if v == config.SeverityNone {
+		return false, false
+	}
+	return check(v, e, e2), true
+}
+
+// isLevelKey tells whether the key called name holds levels, being either $_level or
+// the key playing the level role.
+func isLevelKey(name string, key map[string]*config.Key) bool {
+	if name == config.Level {
+		return true
+	}
+	if k, ok := key[name]; ok && k.Role != config.RoleNone {
+		return k.Role == config.RoleLevel
+	}
+	return slices.Contains((*config.Config)(nil).RoleAliases()[config.RoleLevel], name)
+}
+
+// parseCanonicalSeverity parses the name of a canonical level, such as WARN, whatever
+// its case. Numbers and other level names are left to be compared as they are.
+func parseCanonicalSeverity(expression string) (config.Severity, bool) {
+	s := config.ParseSeverity(expression)
+	return s, s != config.SeverityNone && strings.EqualFold(s.String(), expression)
+}
+
 func (p *Predicate) parseBoolAndCheck(value string, check func(value, expression bool) (bool, error)) (bool, error) {
 	var v, e bool
 	var err error
@@ -502,31 +595,41 @@ func (p *Predicate) parseBoolAndCheck(value string, check func(value, expression
 	return false, err
 }
 
+// parseDateTimeAndCheck compares the instants value and the expression stand for, in
+// whichever format they're in, see config.Key.ParseTime. Values that aren't times
+// don't match, whereas expressions that aren't are errors.
 func (p *Predicate) parseDateTimeAndCheck(value string, key *config.Key, check func(value, expression time.Time) (bool, error)) (bool, error) {
-	var v, e time.Time
-	var err error
-	v, err = time.Parse(key.Layout, value)
-	if err == nil {
-		e, err = time.Parse(key.Layout, p.KeyExpression[0])
-		if err == nil {
-			return check(v, e)
-		}
+	e, err := parseExpressionTime(key, p.KeyExpression[0])
+	if err != nil {
+		return false, err
 	}
-	return false, err
+	v, ok := key.ParseTime(value)
+	if !ok {
+		return false, nil
+	}
+	return check(v, e)
 }
 
 func (f *between) parseDateTimeAndCheck(value string, key *config.Key, check func(value, expression, expression2 time.Time) (bool, error)) (bool, error) {
-	var v, e, e2 time.Time
-	var err error
-	v, err = time.Parse(key.Layout, value)
-	if err == nil {
-		e, err = time.Parse(key.Layout, f.KeyExpression[0])
-		if err == nil {
-			e2, err = time.Parse(key.Layout, f.KeyExpression[1])
-			if err == nil {
-				return check(v, e, e2)
-			}
-		}
+	e, err := parseExpressionTime(key, f.KeyExpression[0])
+	if err != nil {
+		return false, err
 	}
-	return false, err
+	e2, err := parseExpressionTime(key, f.KeyExpression[1])
+	if err != nil {
+		return false, err
+	}
+	v, ok := key.ParseTime(value)
+	if !ok {
+		return false, nil
+	}
+	return check(v, e, e2)
+}
+
+func parseExpressionTime(key *config.Key, expression string) (time.Time, error) {
+	t, ok := key.ParseTime(expression)
+	if !ok {
+		return t, fmt.Errorf("%s isn't a time of %s", expression, key.Name)
+	}
+	return t, nil
 }
@@ -55,6 +55,12 @@ var keySet = map[string]*config.Key{
 		Type:   config.TypeDateTime,
 		Layout: "2006-01-02T15:04:05-0700",
 	},
+	"tsKey": {
+		Name:    "ts",
+		Type:    config.TypeDateTime,
+		Layout:  "02/01/2006 15:04:05",
+		Layouts: []string{"2006-01-02 15:04:05.000 MST"},
+	},
 }
 
 func TestEqual_Apply(t *testing.T) {
@@ -115,6 +121,41 @@ func TestEqual_Apply(t *testing.T) {
 			shouldMatch: false,
 			wantError:   false,
 		},
+		{
+			name:        "Wants exact 64-bit NUMBER match",
+			filter:      Equals("numbKey", "1234567890123456789"),
+			whenValue:   "1234567890123456789",
+			shouldMatch: true,
+			wantError:   false,
+		},
+		{
+			name:        "No 64-bit NUMBER match beyond float precision",
+			filter:      Equals("numbKey", "1234567890123456789"),
+			whenValue:   "1234567890123456788",
+			shouldMatch: false,
+			wantError:   false,
+		},
+		{
+			name:        "Wants integer NUMBER match of a float",
+			filter:      Equals("numbKey", "1000"),
+			whenValue:   "1e3",
+			shouldMatch: true,
+			wantError:   false,
+		},
+		{
+			name:        "No integer NUMBER match of a float it rounds to",
+			filter:      Equals("numbKey", "9007199254740992.0"),
+			whenValue:   "9007199254740993",
+			shouldMatch: false,
+			wantError:   false,
+		},
+		{
+			name:        "Wants BAD NaN number",
+			filter:      Equals("numbKey", "1"),
+			whenValue:   "NaN",
+			shouldMatch: false,
+			wantError:   true,
+		},
 		{
 			name:        "Wants BAD number on value",
 			filter:      Equals("numbKey", "0.0109"),
@@ -144,11 +185,11 @@ func TestEqual_Apply(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      Equals("dateTimeKey", "2006-01-02T15:04:05-0700"),
 			whenValue:   "bananas",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -252,11 +293,11 @@ func TestNotEqual_Apply(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      Equals("dateTimeKey", "2006-01-02T15:04:05-0700"),
 			whenValue:   "bananas",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -413,6 +454,13 @@ func TestBetween(t *testing.T) {
 			shouldMatch: false,
 			wantError:   false,
 		},
+		{
+			name:        "Wants 64-bit NUMBER match",
+			filter:      Between("numbKey", "1234567890123456788", "1234567890123456790"),
+			whenValue:   "1234567890123456789",
+			shouldMatch: true,
+			wantError:   false,
+		},
 		{
 			name:        "No NUMBER match - not inclusive",
 			filter:      Between("numbKey", "1", "2"),
@@ -456,11 +504,11 @@ func TestBetween(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      Between("dateTimeKey", "2006-01-02T15:04:05-0700", "2006-03-02T15:04:05-0700"),
 			whenValue:   "asz",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -550,11 +598,11 @@ func TestBetweenInclusive(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      BetweenInclusive("dateTimeKey", "2006-01-02T15:04:05-0700", "2006-03-02T15:04:05-0700"),
 			whenValue:   "asz",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -608,6 +656,20 @@ func TestLowerThan_Apply(t *testing.T) {
 			shouldMatch: false,
 			wantError:   false,
 		},
+		{
+			name:        "Wants nanosecond epoch NUMBER match",
+			filter:      LowerThan("numbKey", "1759320000123456790"),
+			whenValue:   "1759320000123456789",
+			shouldMatch: true,
+			wantError:   false,
+		},
+		{
+			name:        "Wants integer beyond 64 bits NUMBER match",
+			filter:      LowerThan("numbKey", "123456789012345678901234567891"),
+			whenValue:   "123456789012345678901234567890",
+			shouldMatch: true,
+			wantError:   false,
+		},
 		{
 			name:        "Wants BAD number on value",
 			filter:      LowerThan("numbKey", "0.0109"),
@@ -637,11 +699,11 @@ func TestLowerThan_Apply(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      LowerThan("dateTimeKey", "2006-01-02T15:04:05-0700"),
 			whenValue:   "bananas",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -717,11 +779,11 @@ func TestLowerOrEqualThan_Apply(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      LowerOrEqualThan("dateTimeKey", "2006-01-02T15:04:05-0700"),
 			whenValue:   "bananas",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -797,11 +859,11 @@ func TestGreaterThan_Apply(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      GreaterThan("dateTimeKey", "2006-01-02T15:04:05-0700"),
 			whenValue:   "bananas",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -877,11 +939,11 @@ func TestGreaterOrEqualThan_Apply(t *testing.T) {
 			wantError:   false,
 		},
 		{
-			name:        "Wants BAD DATE value",
+			name:        "Skips BAD DATE value",
 			filter:      GreaterOrEqualThan("dateTimeKey", "2006-01-02T15:04:05-0700"),
 			whenValue:   "bananas",
 			shouldMatch: false,
-			wantError:   true,
+			wantError:   false,
 		},
 		{
 			name:        "Wants BAD DATE expression",
@@ -898,6 +960,76 @@ func TestGreaterOrEqualThan_Apply(t *testing.T) {
 	}
 }
 
+func TestDateTime_Apply(t *testing.T) {
+	tests := []testFilter{
+		{
+			name:        "Layout against RFC 3339",
+			filter:      Equals("tsKey", "2026-10-01T12:00:00Z"),
+			whenValue:   "01/10/2026 12:00:00",
+			shouldMatch: true,
+		},
+		{
+			name:        "Second layout",
+			filter:      Equals("tsKey", "2026-10-01T12:00:00.250Z"),
+			whenValue:   "2026-10-01 12:00:00.250 UTC",
+			shouldMatch: true,
+		},
+		{
+			name:        "Epoch seconds with fraction",
+			filter:      Equals("tsKey", "2026-10-01T14:00:00.5+02:00"),
+			whenValue:   "1790856000.5",
+			shouldMatch: true,
+		},
+		{
+			name:        "Epoch milliseconds",
+			filter:      GreaterThan("tsKey", "2026-10-01T12:00:00Z"),
+			whenValue:   "1790856000001",
+			shouldMatch: true,
+		},
+		{
+			name:        "Epoch microseconds",
+			filter:      LowerThan("tsKey", "1790856000"),
+			whenValue:   "1790855999999999",
+			shouldMatch: true,
+		},
+		{
+			name:        "Epoch nanoseconds",
+			filter:      Between("tsKey", "01/10/2026 11:59:59", "2026-10-01T12:00:01Z"),
+			whenValue:   "1790856000123456789",
+			shouldMatch: true,
+		},
+		{
+			name:        "Epoch nanoseconds out of range",
+			filter:      BetweenInclusive("tsKey", "01/10/2026 11:59:59", "01/10/2026 12:00:00"),
+			whenValue:   "1790856000123456789",
+			shouldMatch: false,
+		},
+		{
+			name:        "Value in another format than the expression",
+			filter:      NotEquals("dateTimeKey", "2006-01-02T15:04:05-0700"),
+			whenValue:   "2006-01-02T22:04:05Z",
+			shouldMatch: false,
+		},
+		{
+			name:        "Missing value",
+			filter:      GreaterThan("tsKey", "2026-10-01T12:00:00Z"),
+			whenValue:   "",
+			shouldMatch: false,
+		},
+		{
+			name:      "Wants BAD DATE expression",
+			filter:    GreaterThan("tsKey", "yesterday"),
+			whenValue: "1790856000",
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testFilterFunc(t, test)
+		})
+	}
+}
+
 func testFilterFunc(t *testing.T, test testFilter) {
 	got, err := test.filter.Apply(test.whenValue, keySet)
 	if test.wantError {
@@ -41,12 +41,13 @@ const (
 
 var (
 	sqlLexer = lexer.MustSimple([]lexer.SimpleRule{
-		{`Keyword`, `(?i)\b(MATCH|CONTAINSIC|CONTAINS|BETWEEN|AND|OR)\b`},
-		{`Ident`, `[a-zA-Z_][a-zA-Z0-9_./]*`},
-		{`Number`, `[-+]?\d*\.?\d+([eE][-+]?\d+)?`},
-		{`String`, `'[^']*'|"[^"]*"`},
-		{`Operators`, `<>|!=|<=|>=|==|[()=<>]`},
-		{"whitespace", `\s+`},
+		{Name: `Keyword`, Pattern: `(?i)\b(MATCH|CONTAINSIC|CONTAINS|BETWEEN|AND|OR)\b`},
+		// Key paths, see config.Path, such as a/b, items/-1/id, items/*/id or a/"b/c"
+		{Name: `Ident`, Pattern: `[$@]?[a-zA-Z_](?:[a-zA-Z0-9_.@\-]|\\.)*(?:/(?:(?:[a-zA-Z0-9_.@$\-*]|\\.)+|"(?:[^"\\]|\\.)*"))*`},
+		{Name: `Number`, Pattern: `[-+]?\d*\.?\d+([eE][-+]?\d+)?`},
+		{Name: `String`, Pattern: `'[^']*'|"[^"]*"`},
+		{Name: `Operators`, Pattern: `<>|!=|<=|>=|==|[()=<>]`},
+		{Name: "whitespace", Pattern: `\s+`},
 	})
 
 	cachedDef = make(map[string]Filter)
@@ -104,53 +105,57 @@ func (o *LogicalOperator) Capture(s []string) error {
 }
 
 type Expression struct {
-	Left  *Term     `@@`
-	Right []*OpTerm `@@*`
+	Left  *Term     `parser:"@@"`
+	Right []*OpTerm `parser:"@@*"`
 }
 
 type ConditionElement struct {
-	Condition     *Condition   ` @@`
-	GlobalToken   *GlobalToken `| @@ `
-	Subexpression *Expression  `| "(" @@ ")"`
+	Condition     *Condition   `parser:" @@"`
+	GlobalToken   *GlobalToken `parser:"| @@ "`
+	Subexpression *Expression  `parser:"| '(' @@ ')'"`
 }
 
 type GlobalToken struct {
-	String *string `@String`
+	String *string `parser:"@String"`
 }
 
 type Condition struct {
-	Operand  string `@Ident`
-	Operator string `@( "<>" | "<=" | ">=" | "=" | "==" | "<" | ">" | "!=" | "BETWEEN" | "CONTAINS" | "CONTAINSIC" | "MATCH" )`
-	Value    *Value `@@`
-	Value2   *Value `( "AND" @@ )*`
+	Operand  string `parser:"@Ident"`
+	Operator string `parser:"@( '<>' | '<=' | '>=' | '=' | '==' | '<' | '>' | '!=' | 'BETWEEN' | 'CONTAINS' | 'CONTAINSIC' | 'MATCH' )"`
+	Value    *Value `parser:"@@"`
+	Value2   *Value `parser:"( 'AND' @@ )*"`
 }
 
 func (v *Value) ToString() string {
 	if v.Number == nil {
 		return *v.String
 	} else {
-		return fmt.Sprintf(`%f`, *v.Number)
+		return *v.Number
 	}
 }
 
+// Value is a literal of an expression. Numbers are kept as they're written, to be
+// compared exactly with integers beyond the precision of float64. Canonical levels
+// may be left unquoted, e.g. level >= WARN, while keys such as ERROR or WARN/count
+// still lex as identifiers.
 type Value struct {
-	Number *float64 `( @Number`
-	String *string  ` | @String )`
+	Number *string `parser:"( @Number"`
+	String *string `parser:" | @(String | 'TRACE' | 'DEBUG' | 'INFO' | 'NOTICE' | 'WARN' | 'ERROR' | 'FATAL') )"`
 }
 
 type OpValue struct {
-	Operator         LogicalOperator   `@("AND")`
-	ConditionElement *ConditionElement `@@`
+	Operator         LogicalOperator   `parser:"@('AND')"`
+	ConditionElement *ConditionElement `parser:"@@"`
 }
 
 type Term struct {
-	Left  *ConditionElement `@@`
-	Right []*OpValue        `@@*`
+	Left  *ConditionElement `parser:"@@"`
+	Right []*OpValue        `parser:"@@*"`
 }
 
 type OpTerm struct {
-	Operator LogicalOperator `@("OR")`
-	Term     *Term           `@@`
+	Operator LogicalOperator `parser:"@('OR')"`
+	Term     *Term           `parser:"@@"`
 }
 
 func (c LogicalOperator) Apply(l, r bool) bool {
@@ -225,7 +230,17 @@ func (c *Condition) Apply(row map[string]interface{}, key map[string]*config.Key
 			Type: config.TypeString,
 		}
 	}
-	return fi.Apply(k.ExtractValue(row), key)
+	// A condition on a path with wildcards holds if it holds for any value found.
+	values := k.ExtractValues(row)
+	if len(values) == 0 {
+		return fi.Apply("", key)
+	}
+	for _, v := range values {
+		if ok, err := fi.Apply(v, key); ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
 }
 
 func (c *Term) Apply(row map[string]interface{}, key map[string]*config.Key) (bool, error) {
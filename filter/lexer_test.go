@@ -24,6 +24,7 @@ package filter
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/jimbertools/loggo/config"
@@ -61,6 +62,22 @@ func TestParseFilterExpression(t *testing.T) {
 			givenExpression: `((a/b = "x" OR a/b = "y") AND (c between 1 AND 3 OR c > 5))`,
 			wantsResult:     true,
 		},
+		{
+			name: `wants true - filter by source`,
+			whenJsonRow: `
+					{
+						"$_source": "/var/log/api.log",
+						"c": "2"
+					}`,
+			keySet: map[string]*config.Key{
+				"$_source": {
+					Name: "$_source",
+					Type: config.TypeString,
+				},
+			},
+			givenExpression: `$_source CONTAINS "api" AND c = "2"`,
+			wantsResult:     true,
+		},
 		{
 			name: `wants true - global token`,
 			whenJsonRow: `
@@ -314,11 +331,212 @@ func TestParseFilterExpression(t *testing.T) {
 			},
 			wantsResult: true,
 		},
+		{
+			name: `wants true - any of the array elements`,
+			whenJsonRow: `
+					{
+						"items": [{"id": "a"}, {"id": "b"}, {"id": "c"}]
+					}`,
+			givenExpression: `items/*/id == "b" AND items/-1/id == "c" AND items/0/id != "b"`,
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - none of the array elements`,
+			whenJsonRow: `
+					{
+						"items": [{"id": "a"}, {"id": "b"}]
+					}`,
+			givenExpression: `items/*/id == "c"`,
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - keys with slashes`,
+			whenJsonRow: `
+					{
+						"logging.googleapis.com/trace": "projects/p/traces/abc",
+						"jsonPayload": {"logging.googleapis.com/spanId": "123"},
+						"@l": "Warning"
+					}`,
+			givenExpression: `logging.googleapis.com/trace CONTAINS "abc" AND ` +
+				`jsonPayload/"logging.googleapis.com/spanId" == "123" AND @l = "warning"`,
+			wantsResult: true,
+		},
+		{
+			name: `wants true - pino level at least WARN`,
+			whenJsonRow: `
+					{
+						"level": 50,
+						"msg": "failed"
+					}`,
+			givenExpression: `level >= WARN AND msg = "failed"`,
+			keySet: map[string]*config.Key{
+				"level": {
+					Name: "level",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants false - syslog notice below WARN`,
+			whenJsonRow: `
+					{
+						"severity": "5"
+					}`,
+			givenExpression: `severity >= WARN`,
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - vendor level between INFO and ERROR`,
+			whenJsonRow: `
+					{
+						"level": "Warning"
+					}`,
+			givenExpression: `level BETWEEN INFO AND ERROR`,
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - canonical level`,
+			whenJsonRow: `
+					{
+						"level": 40,
+						"$_level": "WARN"
+					}`,
+			givenExpression: `$_level == WARN AND level < 45`,
+			keySet: map[string]*config.Key{
+				"level": {
+					Name: "level",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants true - level names as keys`,
+			whenJsonRow: `
+					{
+						"ERROR": "timeout",
+						"WARN": {"count": 3}
+					}`,
+			givenExpression: `ERROR = "timeout" AND WARN/count > 2`,
+			keySet: map[string]*config.Key{
+				"WARN/count": {
+					Name: "WARN/count",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants false - levels of other keys compare as strings`,
+			whenJsonRow: `
+					{
+						"status": "ERROR"
+					}`,
+			givenExpression: `status >= WARN`,
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - key playing the level role`,
+			whenJsonRow: `
+					{
+						"sev": "ERROR"
+					}`,
+			givenExpression: `sev >= WARN`,
+			keySet: map[string]*config.Key{
+				"sev": {
+					Name: "sev",
+					Type: config.TypeString,
+					Role: config.RoleLevel,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants true - 64-bit id`,
+			whenJsonRow: `
+					{
+						"id": 1234567890123456789
+					}`,
+			givenExpression: `id == 1234567890123456789`,
+			keySet: map[string]*config.Key{
+				"id": {
+					Name: "id",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants false - 64-bit id off by one`,
+			whenJsonRow: `
+					{
+						"id": 1234567890123456788
+					}`,
+			givenExpression: `id == 1234567890123456789`,
+			keySet: map[string]*config.Key{
+				"id": {
+					Name: "id",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: false,
+		},
+		{
+			name: `wants true - nanosecond epochs`,
+			whenJsonRow: `
+					{
+						"ts": 1759320000123456789
+					}`,
+			givenExpression: `ts BETWEEN 1759320000123456789 AND 1759320000123456790 AND ts > 1759320000123456788`,
+			keySet: map[string]*config.Key{
+				"ts": {
+					Name: "ts",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants true - number literal on a string key`,
+			whenJsonRow: `
+					{
+						"code": 200
+					}`,
+			givenExpression: `code == 200`,
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - derived keys`,
+			whenJsonRow: `
+					{
+						"start": 1759320000123,
+						"end": 1759320000456,
+						"url": "https://api.example.com/v1/items"
+					}`,
+			givenExpression: `latency > 300 AND host == "api.example.com"`,
+			keySet: map[string]*config.Key{
+				"latency": {
+					Name:   "latency",
+					Type:   config.TypeNumber,
+					Derive: &config.Derive{Expression: "end - start"},
+				},
+				"host": {
+					Name:   "host",
+					Type:   config.TypeString,
+					Derive: &config.Derive{From: "url", Regex: `^\w+://([^/:]+)`},
+				},
+			},
+			wantsResult: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			// Numbers are decoded as records are, see parser.DecodeJSON
 			var row map[string]interface{}
-			err := json.Unmarshal([]byte(test.whenJsonRow), &row)
+			dec := json.NewDecoder(strings.NewReader(test.whenJsonRow))
+			dec.UseNumber()
+			err := dec.Decode(&row)
 			assert.NoError(t, err)
 			exp, err := ParseFilterExpression(test.givenExpression)
 			assert.NoError(t, err)
@@ -333,3 +551,10 @@ func TestParseFilterExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilterExpression_UnquotedValues(t *testing.T) {
+	_, err := ParseFilterExpression(`level >= WARN`)
+	assert.NoError(t, err)
+	_, err = ParseFilterExpression(`status = WARNING`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// number is a parsed number. Integers are kept exact whatever their size, so that
+// 64-bit IDs or nanosecond epochs, which float64 would round off, compare exactly.
+type number struct {
+	// integer is nil unless the number is an integer.
+	integer *big.Int
+	float   float64
+}
+
+func parseNumber(s string) (number, error) {
+	if i, ok := new(big.Int).SetString(s, 10); ok {
+		return number{integer: i}, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil && math.IsNaN(f) {
+		err = &strconv.NumError{Func: "ParseFloat", Num: s, Err: strconv.ErrSyntax}
+	}
+	return number{float: f}, err
+}
+
+// cmp compares n and o, returning -1, 0 or +1 as n is lower than, equal to or greater
+// than o. An integer and a float are compared exactly as well.
+func (n number) cmp(o number) int {
+	if n.integer != nil && o.integer != nil {
+		return n.integer.Cmp(o.integer)
+	}
+	if n.integer == nil && o.integer == nil {
+		switch {
+		case n.float < o.float:
+			return -1
+		case n.float > o.float:
+			return 1
+		}
+		return 0
+	}
+	return n.bigFloat().Cmp(o.bigFloat())
+}
+
+func (n number) bigFloat() *big.Float {
+	if n.integer != nil {
+		return new(big.Float).SetInt(n.integer)
+	}
+	return big.NewFloat(n.float)
+}
@@ -23,7 +23,11 @@ THE SOFTWARE.
 package loggo
 
 import (
+	"context"
+	"sync"
+
 	"github.com/gdamore/tcell/v2"
+	"github.com/jimbertools/loggo/color"
 	"github.com/jimbertools/loggo/config"
 	"github.com/jimbertools/loggo/reader"
 	"github.com/jimbertools/loggo/util"
@@ -35,6 +39,14 @@ type ViewerOption func(*viewerConfig)
 type viewerConfig struct {
 	templateFile string
 	offset       int64
+	noFollow     bool
+	checkpoints  bool
+	resume       bool
+	parser       *config.ParserConfig
+	multiline    *config.MultilineConfig
+	preset       string
+	redaction    *config.Redaction
+	timezone     string
 }
 
 func WithTemplate(templateFile string) ViewerOption {
@@ -50,6 +62,115 @@ func WithOffset(offset int64) ViewerOption {
 	}
 }
 
+// WithNoFollow reads the input to the end instead of following it for new lines.
+func WithNoFollow() ViewerOption {
+	return func(c *viewerConfig) {
+		c.noFollow = true
+	}
+}
+
+// WithCheckpoints saves where each file was left off as the viewer quits, so that a
+// later session can resume from there.
+func WithCheckpoints() ViewerOption {
+	return func(c *viewerConfig) {
+		c.checkpoints = true
+	}
+}
+
+// WithResume resumes files from where the last session left off, saving where they're
+// left off this time as WithCheckpoints does.
+func WithResume() ViewerOption {
+	return func(c *viewerConfig) {
+		c.resume = true
+	}
+}
+
+// WithParser parses the lines as set up by c, overriding the parser of the template.
+func WithParser(c *config.ParserConfig) ViewerOption {
+	return func(vc *viewerConfig) {
+		vc.parser = c
+	}
+}
+
+// WithMultiline joins multi-line records as set up by c, overriding the template.
+func WithMultiline(c *config.MultilineConfig) ViewerOption {
+	return func(vc *viewerConfig) {
+		vc.multiline = c
+	}
+}
+
+// WithPreset lays the logs out with the named built-in template when there's no
+// template, instead of the preset fitting them. With config.NoPreset, the keys are
+// derived from the logs.
+func WithPreset(name string) ViewerOption {
+	return func(vc *viewerConfig) {
+		vc.preset = name
+	}
+}
+
+// WithRedaction masks sensitive values with the rules of r, on top of the ones of the
+// template and the user's.
+func WithRedaction(r *config.Redaction) ViewerOption {
+	return func(vc *viewerConfig) {
+		vc.redaction = r
+	}
+}
+
+// WithTimezone shows datetime keys in the named timezone, see config.LoadTimezone,
+// rather than the one of the template.
+func WithTimezone(name string) ViewerOption {
+	return func(vc *viewerConfig) {
+		vc.timezone = name
+	}
+}
+
+// applyTo adds the redaction rules and the timezone given as options to cfg.
+func (c *viewerConfig) applyTo(cfg *config.Config) {
+	cfg.AddRedaction(c.redaction)
+	if len(c.timezone) > 0 {
+		cfg.Timezone = c.timezone
+	}
+}
+
+// readerOptions translates the viewer options into reader ones.
+func (c *viewerConfig) readerOptions() []reader.Option {
+	opts := []reader.Option{
+		reader.WithOffset(c.offset),
+		reader.WithFollow(!c.noFollow),
+	}
+	if c.checkpoints {
+		opts = append(opts, reader.WithCheckpoints())
+	}
+	if c.resume {
+		opts = append(opts, reader.WithResume())
+	}
+	return opts
+}
+
+// newLoggoApp builds the app rendering r according to templateFile, or else the preset,
+// with the parser and multi-line joining given as options if any.
+func (c *viewerConfig) newLoggoApp(r reader.Reader, templateFile string) (*LoggoApp, error) {
+	var cfg *config.Config
+	var err error
+	if len(templateFile) == 0 && len(c.preset) > 0 && c.preset != config.NoPreset {
+		cfg, err = config.MakeBuiltinConfig(c.preset)
+	} else {
+		cfg, err = config.MakeConfig(templateFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg.NoAutoPreset = c.preset == config.NoPreset
+	if c.parser != nil {
+		cfg.Parser = c.parser
+	}
+	if c.multiline != nil {
+		cfg.Multiline = c.multiline
+	}
+	c.applyTo(cfg)
+	return NewLoggoAppWithConfig(r, cfg)
+}
+
 type LoggoApp struct {
 	appScaffold
 	chanReader reader.Reader
@@ -66,49 +187,234 @@ type Loggo interface {
 	ShowModal(p tview.Primitive, width, height int, bgColor tcell.Color, capture inputCapture)
 	DismissModal(resetFocusTo tview.Primitive)
 	Config() *config.Config
+	Theme() color.Theme
 	StackView(p tview.Primitive)
 	PopView()
 }
 
-func StartLogViewer(fileName string, opts ...ViewerOption) {
+func StartLogViewer(fileName string, opts ...ViewerOption) error {
 	c := viewerConfig{}
 
 	for _, opt := range opts {
 		opt(&c)
 	}
 
-	myReader := reader.MakeReader(fileName, reader.WithOffset(c.offset))
-	app := NewLoggoApp(myReader, c.templateFile)
-	app.Run()
+	myReader := reader.MakeReader(fileName, c.readerOptions()...)
+	defer myReader.Close()
+	app, err := c.newLoggoApp(myReader, c.templateFile)
+	if err != nil {
+		return err
+	}
+	return app.Run()
 }
 
-func StartMultiFileLogViewer(fileNames []string, templateFile string) {
-	myReader := reader.MakeMultiReader(fileNames, nil)
-	app := NewLoggoApp(myReader, templateFile)
-	app.Run()
+func StartMultiFileLogViewer(fileNames []string, templateFile string, opts ...ViewerOption) error {
+	c := viewerConfig{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	myReader := reader.MakeMultiReader(fileNames, nil, c.readerOptions()...)
+	defer myReader.Close()
+	app, err := c.newLoggoApp(myReader, templateFile)
+	if err != nil {
+		return err
+	}
+	return app.Run()
 }
 
-func NewLoggoApp(reader reader.Reader, configFile string) *LoggoApp {
-	app := NewApp(configFile)
+// StartWatchLogViewer streams every file matching the glob patterns, including files
+// created while the viewer is running. WithOffset doesn't apply.
+func StartWatchLogViewer(patterns []string, templateFile string, opts ...ViewerOption) error {
+	c := viewerConfig{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	myReader := reader.MakeWatchReader(patterns, nil, c.readerOptions()...)
+	defer myReader.Close()
+	app, err := c.newLoggoApp(myReader, templateFile)
+	if err != nil {
+		return err
+	}
+	return app.Run()
+}
+
+// StartSyslogLogViewer listens for syslog messages at address, e.g. udp://:5514. Of the
+// options, only WithRedaction and WithTimezone apply.
+func StartSyslogLogViewer(address string, templateFile string, opts ...ViewerOption) error {
+	c := viewerConfig{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	myReader := reader.MakeSyslogReader(address, nil)
+	defer myReader.Close()
+	cfg, err := config.MakeConfig(templateFile)
+	if err != nil {
+		return err
+	}
+	c.applyTo(cfg)
+	app, err := NewLoggoAppWithConfig(myReader, cfg)
+	if err != nil {
+		return err
+	}
+	return app.Run()
+}
+
+// StartExecLogViewer runs command and streams its output, restarting it whenever it
+// exits.
+func StartExecLogViewer(command string, templateFile string, opts ...ViewerOption) error {
+	c := viewerConfig{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	myReader := reader.MakeExecReader(command, reader.WithFollow(!c.noFollow))
+	defer myReader.Close()
+	app, err := c.newLoggoApp(myReader, templateFile)
+	if err != nil {
+		return err
+	}
+	return app.Run()
+}
+
+// StartJournaldLogViewer streams journalctl output in json or export format, from
+// fileName or else the piped input. Without templateFile, the built-in journald
+// template is used. Of the options, only WithRedaction and WithTimezone apply.
+func StartJournaldLogViewer(fileName string, templateFile string, opts ...ViewerOption) error {
+	c := viewerConfig{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	myReader := reader.MakeJournaldReader(fileName, nil)
+	defer myReader.Close()
+	var cfg *config.Config
+	var err error
+	if len(templateFile) > 0 {
+		cfg, err = config.MakeConfig(templateFile)
+	} else {
+		cfg, err = config.MakeBuiltinConfig("journald")
+	}
+	if err != nil {
+		return err
+	}
+	c.applyTo(cfg)
+	app, err := NewLoggoAppWithConfig(myReader, cfg)
+	if err != nil {
+		return err
+	}
+	return app.Run()
+}
+
+// NewLoggoApp builds a log viewer app streaming from reader and rendering according to
+// configFile, failing if the template can't be loaded.
+func NewLoggoApp(reader reader.Reader, configFile string) (*LoggoApp, error) {
+	cfg, err := config.MakeConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoggoAppWithConfig(reader, cfg)
+}
+
+// NewLoggoAppWithConfig builds a log viewer app streaming from reader and rendering
+// according to an already loaded template config.
+// Docker and Kubernetes container logs read from files or piped in are unwrapped on
+// the way.
+// Multi-line records are joined as set up by the config, if it does, failing if its
+// patterns are invalid.
+func NewLoggoAppWithConfig(r reader.Reader, cfg *config.Config) (*LoggoApp, error) {
+	return NewLoggoAppWithTheme(r, cfg, color.DefaultTheme)
+}
+
+// NewLoggoAppWithTheme builds a log viewer app like NewLoggoAppWithConfig, its widgets
+// being rendered with theme rather than the default one.
+func NewLoggoAppWithTheme(r reader.Reader, cfg *config.Config, theme color.Theme) (*LoggoApp, error) {
+	framed := r
+	if holdsContainerLogs(r) {
+		framed = reader.Frame(r, reader.NewContainerFramer)
+	}
+	if m := cfg.Multiline; m != nil && m.JSON {
+		framed = reader.Frame(framed, reader.MakeJSONFramer(m.MaxLines, m.Timeout))
+	} else if m != nil {
+		newFramer, err := reader.MakeMultilineFramer(m.Start, m.Continuation, m.MaxLines, m.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		framed = reader.Frame(framed, newFramer)
+	}
+	app := NewAppWithConfig(cfg)
+	app.theme = theme
 	lapp := &LoggoApp{
 		appScaffold: *app,
-		chanReader:  reader,
+		chanReader:  framed,
 	}
 
-	lapp.logView = NewLogReader(lapp, reader)
+	lapp.logView = NewLogReader(lapp, framed)
 
 	lapp.pages = tview.NewPages().
 		AddPage("background", lapp.logView, true, true)
 
-	return lapp
+	return lapp, nil
 }
 
-func (a *LoggoApp) Run() {
-	if err := a.app.
-		SetRoot(a.pages, true).
-		EnableMouse(true).
-		Run(); err != nil {
+// holdsContainerLogs tells whether r may stream container log files, as only files and
+// the piped input can. Other sources, such as syslog or journald, are left untouched,
+// while readers of no known type are taken for pipes, as reader.Frame does.
+func holdsContainerLogs(r reader.Reader) bool {
+	typed, ok := r.(interface{ Type() reader.Type })
+	if !ok {
+		return true
+	}
+	switch typed.Type() {
+	case reader.TypeFile, reader.TypePipe, reader.TypeMultiFile, reader.TypeWatch:
+		return true
+	}
+	return false
+}
+
+// Run runs the app until the UI quits.
+func (a *LoggoApp) Run() error {
+	if err := a.RunContext(context.Background()); err != nil {
 		util.Log().Error(err)
-		panic(err)
+		return err
 	}
+	return nil
+}
+
+// RunContext runs the app until the UI quits or ctx is done, whichever happens first.
+func (a *LoggoApp) RunContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.Stop()
+		case <-done:
+		}
+	}()
+	// The logs start streaming on the first draw, from the UI goroutine.
+	var start sync.Once
+	a.app.SetBeforeDrawFunc(func(tcell.Screen) bool {
+		start.Do(a.logView.start)
+		return false
+	})
+	return a.app.
+		SetRoot(a.pages, true).
+		EnableMouse(true).
+		Run()
+}
+
+// SetFilter applies a local filter expression to the log stream, as if typed in the filter bar.
+func (a *LoggoApp) SetFilter(expression string) error {
+	return a.logView.SetFilter(expression)
 }
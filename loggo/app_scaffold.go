@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/jimbertools/loggo/color"
 	"github.com/jimbertools/loggo/config"
 	"github.com/rivo/tview"
 )
@@ -38,6 +39,7 @@ type appScaffold struct {
 	modal        *tview.Flex
 	stackPages   []tview.Primitive
 	inputCapture inputCapture
+	theme        color.Theme
 }
 
 type inputCapture func(event *tcell.EventKey) *tcell.EventKey
@@ -63,6 +65,7 @@ func NewAppWithConfig(cfg *config.Config) *appScaffold {
 	scaffold.config = cfg
 	scaffold.stackPages = []tview.Primitive{}
 	scaffold.pages = tview.NewPages()
+	scaffold.theme = color.DefaultTheme
 
 	return scaffold
 }
@@ -71,6 +74,11 @@ func (a *appScaffold) Config() *config.Config {
 	return a.config
 }
 
+// Theme is the palette the widgets of the app are rendered with.
+func (a *appScaffold) Theme() color.Theme {
+	return a.theme
+}
+
 func (a *appScaffold) Draw() {
 	a.app.Draw()
 }
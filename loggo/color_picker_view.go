@@ -27,7 +27,6 @@ import (
 	"sort"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/jimbertools/loggo/color"
 	"github.com/rivo/tview"
 )
 
@@ -105,7 +104,7 @@ func (t *ColorPickerView) makeUIComponents() {
 	t.contextMenu.
 		SetBorder(true).
 		SetTitle("Context Menu").
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(t.app.Theme().Background)
 
 	t.table = tview.NewTable().
 		SetSelectable(true, true).
@@ -144,7 +143,7 @@ func (t *ColorPickerView) makeLayouts() {
 	t.Flex.Clear().SetDirection(tview.FlexColumn).
 		AddItem(t.contextMenu, 30, 1, false).
 		AddItem(t.table, 0, 2, true).
-		SetBackgroundColor(color.ColorBackgroundField).
+		SetBackgroundColor(t.app.Theme().Background).
 		SetBorder(true).
 		SetTitle(t.title)
 }
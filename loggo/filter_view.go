@@ -59,10 +59,10 @@ func NewFilterView(app Loggo, filterCallback func(*filter.Expression)) *FilterVi
 func (t *FilterView) makeUIComponents() {
 	t.expressionField = tview.NewInputField().
 		SetPlaceholder("Filter Expression...").
-		SetFieldStyle(color.FieldStyle).
+		SetFieldStyle(t.app.Theme().FieldStyle()).
 		SetPlaceholderStyle(color.PlaceholderStyle)
 	t.expressionField.
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(t.app.Theme().Background)
 	t.buttonSearch = tview.NewButton("Search").SetSelectedFunc(func() {
 		t.search()
 	})
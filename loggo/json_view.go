@@ -31,6 +31,8 @@ import (
 	"github.com/atotto/clipboard"
 	"github.com/gdamore/tcell/v2"
 	"github.com/jimbertools/loggo/color"
+	"github.com/jimbertools/loggo/config"
+	"github.com/jimbertools/loggo/parser"
 	"github.com/jimbertools/loggo/search"
 	"github.com/rivo/tview"
 )
@@ -40,6 +42,7 @@ type JsonView struct {
 	app                      Loggo
 	textView                 *tview.TextView
 	searchInput              *tview.InputField
+	pathInput                *tview.InputField
 	searchType               *tview.DropDown
 	statusBar                *tview.TextView
 	contextMenu              *tview.List
@@ -90,7 +93,7 @@ func (j *JsonView) makeUIComponents() {
 		SetWrap(j.wordWrap)
 
 	j.textView.
-		SetBackgroundColor(color.ColorBackgroundField).
+		SetBackgroundColor(j.app.Theme().Background).
 		SetBorderPadding(0, 0, 1, 1).
 		SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 			if j.isSearching {
@@ -128,6 +131,9 @@ func (j *JsonView) makeUIComponents() {
 			case 'r', 'R':
 				j.prepareRegexSearch()
 				return nil
+			case 'e', 'E':
+				j.preparePathExtraction()
+				return nil
 			case 'x', 'X':
 				if j.closeCallback != nil {
 					j.closeCallback()
@@ -161,12 +167,12 @@ func (j *JsonView) makeUIComponents() {
 	j.contextMenu.
 		SetBorder(true).
 		SetTitle("Context Menu").
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(j.app.Theme().Background)
 
 	j.searchInput = tview.NewInputField()
-	j.searchInput.SetFieldStyle(color.FieldStyle).
+	j.searchInput.SetFieldStyle(j.app.Theme().FieldStyle()).
 		SetBorder(true).
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(j.app.Theme().Background)
 	j.searchInput.SetChangedFunc(func(text string) {
 		if len(text) == 0 {
 			return
@@ -191,8 +197,33 @@ func (j *JsonView) makeUIComponents() {
 		return event
 	})
 
+	j.pathInput = tview.NewInputField()
+	j.pathInput.SetFieldStyle(j.app.Theme().FieldStyle()).
+		SetTitle("Extract Path").
+		SetBorder(true).
+		SetBackgroundColor(j.app.Theme().Background)
+	j.pathInput.SetChangedFunc(func(text string) {
+		value, _ := j.extractPath(text)
+		j.statusBar.SetText(value)
+	})
+	j.pathInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			j.app.SetFocus(j.textView)
+			j.makeLayouts(false)
+			return nil
+		case tcell.KeyEnter:
+			if value, ok := j.extractPath(j.pathInput.GetText()); ok {
+				_ = clipboard.WriteAll(value)
+				j.app.ShowPopMessage("Copied the value to clipboard", 2, j.pathInput)
+			}
+			return nil
+		}
+		return event
+	})
+
 	j.statusBar = tview.NewTextView()
-	j.statusBar.SetBackgroundColor(color.ColorBackgroundField).SetBorder(true)
+	j.statusBar.SetBackgroundColor(j.app.Theme().Background).SetBorder(true)
 }
 
 func (j *JsonView) makeLayouts(search bool) {
@@ -213,6 +244,17 @@ func (j *JsonView) makeLayouts(search bool) {
 	}
 }
 
+// makePathLayouts shows the path input below the entry, along with the value found.
+func (j *JsonView) makePathLayouts() {
+	j.makeLayouts(false)
+	j.Flex.AddItem(tview.NewFlex().
+		SetDirection(tview.FlexColumn).
+		AddItem(j.pathInput, 30, 1, false).
+		AddItem(j.statusBar.Clear(), 0, 1, false),
+		3, 1, false,
+	)
+}
+
 func (j *JsonView) makeContextMenu() {
 	j.contextMenu.Clear().ShowSecondaryText(false).SetBorderPadding(0, 0, 1, 1)
 	j.contextMenu.
@@ -246,6 +288,9 @@ func (j *JsonView) makeContextMenu() {
 		AddItem("Search Regex", "", 'r', func() {
 			j.prepareRegexSearch()
 		}).
+		AddItem("Extract Path", "", 'e', func() {
+			j.preparePathExtraction()
+		}).
 		AddItem("Go to Top", "", 'g', func() {
 			j.textView.ScrollToBeginning()
 		}).
@@ -282,8 +327,7 @@ func (j *JsonView) copyToClipboard() {
 	j.app.ShowPopMessage(fmt.Sprintf(`Copied %s to clipboard`, size), 2, j.textView)
 	// Attempt formatting
 	b := j.jText
-	m := make(map[string]any)
-	err := json.Unmarshal(b, &m)
+	m, err := parser.DecodeJSON(b)
 	if err == nil {
 		b2, err := json.MarshalIndent(m, "", "  ")
 		if err == nil {
@@ -320,6 +364,33 @@ func (j *JsonView) prepareRegexSearch() {
 	}
 }
 
+// preparePathExtraction asks for a key path, e.g. items/-1/id, showing the value it
+// leads to in the entry as a template key or the filter would find it.
+func (j *JsonView) preparePathExtraction() {
+	j.makePathLayouts()
+	j.app.SetFocus(j.pathInput)
+	if len(j.pathInput.GetText()) > 0 {
+		value, _ := j.extractPath(j.pathInput.GetText())
+		j.statusBar.SetText(value)
+	}
+}
+
+// extractPath returns the value found at path in the entry, or else why there's none.
+func (j *JsonView) extractPath(path string) (string, bool) {
+	m, err := parser.DecodeJSON(j.jText)
+	if err != nil {
+		return "The entry isn't JSON", false
+	}
+	if _, err := config.ParsePath(path); err != nil {
+		return err.Error(), false
+	}
+	k := config.Key{Name: path}
+	if values := k.ExtractValues(m); len(values) == 0 {
+		return "Nothing found", false
+	}
+	return k.ExtractValue(m), true
+}
+
 func (j *JsonView) search(word string) []int {
 	j.isSearching = true
 	j.isCopyMode = false
@@ -365,8 +436,8 @@ func (j *JsonView) clearSearch() {
 }
 
 func (j *JsonView) setJson() *JsonView {
-	jMap := make(map[string]interface{})
-	if err := json.Unmarshal(j.jText, &jMap); err != nil {
+	// Numbers are kept as they're written, rather than rounded off as float64.
+	if jMap, err := parser.DecodeJSON(j.jText); err != nil {
 		tex := string(j.jText)
 		sb := strings.Builder{}
 		wordList := strings.Split(tex, " ")
@@ -413,6 +484,7 @@ func (j *JsonView) processNode(k, v interface{}, indent string, text *strings.Bu
 	switch tp := v.(type) {
 	case int,
 		float64,
+		json.Number,
 		bool:
 		j.processNumeric(text, v, "")
 	case string:
@@ -498,6 +570,7 @@ func (j *JsonView) processArrayItem(v interface{}, indent string, text *strings.
 	switch tp := v.(type) {
 	case int,
 		float64,
+		json.Number,
 		bool:
 		j.processNumeric(text, v, "")
 	case string:
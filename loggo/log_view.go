@@ -25,11 +25,12 @@ package loggo
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/jimbertools/loggo/color"
 	"github.com/jimbertools/loggo/config"
 	"github.com/jimbertools/loggo/filter"
 	"github.com/jimbertools/loggo/reader"
@@ -52,6 +53,7 @@ type LogView struct {
 	filterView         *FilterView
 	linesView          *tview.TextView
 	followingView      *tview.TextView
+	severityView       *tview.TextView
 	logFullScreen      bool
 	templateFullScreen bool
 	inSlice            []map[string]interface{}
@@ -60,11 +62,21 @@ type LogView struct {
 	currentFilter      *filter.Expression
 	filterLock         sync.RWMutex
 	globalCount        int64
+	severityCounts     [config.SeverityFatal + 1]int64
 	isFollowing        bool
 	hideFilter         bool
 	rebufferFilter     bool
 	selectionEnabled   bool
 	mouseSel           *tview.TextView
+	presetLock         sync.Mutex
+	presetChecked      bool
+	redactor           *config.Redactor
+	redactErr          error
+	reveal             bool
+	revealView         *tview.TextView
+	// timezone is the one datetime keys are shown in, or nil for the one they were
+	// logged in. It's read while rendering, hence atomic.
+	timezone atomic.Pointer[time.Location]
 }
 
 func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
@@ -78,6 +90,10 @@ func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
 		hideFilter:    true,
 		isFollowing:   true,
 	}
+	// Reported once streaming starts, rather than letting records through unmasked.
+	lv.redactor, lv.redactErr = lv.config.Redactor()
+	timezone, _ := config.LoadTimezone(lv.config.Timezone)
+	lv.timezone.Store(timezone)
 
 	lv.makeUIComponents()
 	lv.makeLayouts()
@@ -107,21 +123,31 @@ func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
 			}))
 	})
 
-	lv.read()
-	lv.filter()
-	lv.filterChannel <- nil
+	reader.EventNotifier(lv.showStreamEvent)
+	return lv
+}
+
+// start streams the logs into the view. It's called once the UI runs, for nothing to
+// update the view before then.
+func (l *LogView) start() {
+	l.read()
+	l.filter()
+	// A filter set beforehand is applied as is.
+	select {
+	case l.filterChannel <- nil:
+	default:
+	}
 
 	go func() {
-		lv.app.Draw()
+		l.app.Draw()
 		time.Sleep(2 * time.Second)
-		lv.app.DismissModal(lv.table)
-		lv.app.Draw()
+		l.app.DismissModal(l.table)
+		l.app.Draw()
 
 		time.Sleep(10 * time.Millisecond)
-		lv.isFollowing = true
-		lv.app.SetFocus(lv.table)
+		l.isFollowing = true
+		l.app.SetFocus(l.table)
 	}()
-	return lv
 }
 
 func (l *LogView) makeUIComponents() {
@@ -129,7 +155,7 @@ func (l *LogView) makeUIComponents() {
 		// Toggle full screen func
 		l.templateFullScreen = !l.templateFullScreen
 		l.makeLayoutsWithTemplateView()
-	}, l.makeLayouts)
+	}, l.closeTemplateView)
 	l.templateView.SetBorder(true).SetTitle("Template Editor")
 	l.data = &LogData{
 		logView: l,
@@ -144,10 +170,12 @@ func (l *LogView) makeUIComponents() {
 				}, l.makeLayouts)
 			l.jsonView.SetBorder(true).SetTitle("Log Entry")
 			var b []byte
-			if _, ok := l.finSlice[row-1][config.ParseErr]; ok {
-				b = []byte(fmt.Sprintf(`%v`, l.finSlice[row-1][config.TextPayload]))
+			// What's copied to the clipboard from there is just as redacted.
+			entry := l.shown(l.finSlice[row-1])
+			if _, ok := entry[config.ParseErr]; ok {
+				b = []byte(fmt.Sprintf(`%v`, entry[config.TextPayload]))
 			} else {
-				b, _ = json.Marshal(l.finSlice[row-1])
+				b, _ = json.Marshal(l.config.WithDerived(entry))
 			}
 			l.jsonView.SetJson(b)
 			l.makeLayoutsWithJsonView()
@@ -168,7 +196,7 @@ func (l *LogView) makeUIComponents() {
 			}
 		})
 	l.table.SetSelectedFunc(selection).
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(l.app.Theme().Background)
 	l.table.SetSelectionChangedFunc(func(row, column int) {
 		// stop scrolling!
 		if l.isFollowing {
@@ -197,6 +225,7 @@ func (l *LogView) makeUIComponents() {
 	l.keyEvents()
 
 	l.linesView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight)
+	l.severityView = tview.NewTextView().SetDynamicColors(true)
 	l.followingView = tview.NewTextView().
 		SetRegions(true).
 		SetDynamicColors(true)
@@ -219,6 +248,22 @@ func (l *LogView) makeUIComponents() {
 	})
 }
 
+// SetFilter parses expression and applies it to the log stream. An empty
+// expression clears the current filter.
+func (l *LogView) SetFilter(expression string) error {
+	var exp *filter.Expression
+	if len(strings.TrimSpace(expression)) > 0 {
+		var err error
+		if exp, err = filter.ParseFilterExpression(expression); err != nil {
+			return err
+		}
+	}
+	l.filterView.expressionField.SetText(expression)
+	l.rebufferFilter = true
+	l.filterChannel <- exp
+	return nil
+}
+
 func (l *LogView) toggleFilter() {
 	if l.isJsonViewShown() || l.isTemplateViewShown() {
 		l.hideFilter = false
@@ -239,15 +284,24 @@ func (l *LogView) makeLayouts() {
 	l.Flex.Clear().SetDirection(tview.FlexRow)
 	if !l.hideFilter {
 		l.Flex.AddItem(l.filterView, 4, 2, false).
-			AddItem(NewHorizontalSeparator(color.FieldStyle, LineHThick, "", 0), 1, 2, false)
+			AddItem(NewHorizontalSeparator(l.app.Theme().FieldStyle(), LineHThick, "", 0), 1, 2, false)
 	}
 	l.Flex.AddItem(mainContent, 0, 2, false).
 		//AddItem(l.navMenu, 1, 1, false).
 		//AddItem(l.mainMenu, 1, 1, false).
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(l.app.Theme().Background)
 	l.app.SetFocus(l.table)
 }
 
+// closeTemplateView goes back to the logs, the filter picking up the keys edited in the
+// meantime, such as derived ones.
+func (l *LogView) closeTemplateView() {
+	l.filterLock.Lock()
+	l.keyMap = l.config.KeyMap()
+	l.filterLock.Unlock()
+	l.makeLayouts()
+}
+
 func (l *LogView) isTemplateViewShown() bool {
 	return l.Flex.GetItemCount() > 0 && l.Flex.GetItem(0) == l.templateView ||
 		l.Flex.GetItemCount() > 1 && l.Flex.GetItem(1) == l.templateView
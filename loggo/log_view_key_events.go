@@ -41,6 +41,15 @@ func (l *LogView) keyEvents() {
 		case tcell.KeyCtrlT:
 			l.makeLayoutsWithTemplateView()
 			return nil
+		case tcell.KeyCtrlP:
+			l.showPresets()
+			return nil
+		case tcell.KeyCtrlR:
+			l.toggleReveal()
+			return nil
+		case tcell.KeyCtrlZ:
+			l.showTimezones()
+			return nil
 		case tcell.KeyCtrlSpace:
 			l.toggledFollowing()
 			return nil
@@ -74,7 +83,7 @@ func (l *LogView) keyEvents() {
 		}
 		if prim == l.table && l.isJsonViewShown() {
 			switch event.Rune() {
-			case 'f', '`', 's', 'r', 'g', 'G', 'w', 'x':
+			case 'f', '`', 's', 'r', 'e', 'g', 'G', 'w', 'x':
 				return l.jsonView.textView.GetInputCapture()(event)
 			}
 		}
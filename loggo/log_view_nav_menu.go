@@ -25,9 +25,10 @@ package loggo
 import (
 	"fmt"
 	"runtime"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/jimbertools/loggo/color"
+	"github.com/jimbertools/loggo/config"
 	"github.com/rivo/tview"
 )
 
@@ -35,6 +36,8 @@ const (
 	selectionMouseEnabledMenu  = `[yellow::b] ^n      [-::u]["1"]Enable Selection[""]`
 	selectionMouseDisabledMenu = `[yellow::b] ^n      [-::u]["1"]Enable Mouse[""]`
 	templateMenu               = `[yellow::b] ^t      [-::u]["1"]Template[""]`
+	presetMenu                 = `[yellow::b] ^p      [-::u]["1"]Preset[""]`
+	timezoneMenu               = `[yellow::b] ^z      [-::u]["1"]Timezone[""]`
 	localFilterMenu            = `[yellow::b] :       [-::u]["1"]Local Filter[""]`
 	viewEntryMenu              = `[yellow::b] Enter[-::-]   View Entry`
 	navigateMenu               = `[yellow::b] ↓ ← ↑ →[-::-] Navigate`
@@ -48,6 +51,8 @@ const (
 	quitMenu                   = `[yellow::b] ^c      [-::u]["1"]Quit[""]`
 	autoScrollOnMenu           = `[yellow::b] ^Space  [-::u]["1"]Auto-Scroll[::-] [green::bi]ON[-::-][""]`
 	autoScrollOffMenu          = `[yellow::b] ^Space  [-::u]["1"]Auto-Scroll[::-] [red::bi]OFF[-::-][""]`
+	revealOnMenu               = `[yellow::b] ^r      [-::u]["1"]Reveal[::-] [red::bi]ON[-::-][""]`
+	revealOffMenu              = `[yellow::b] ^r      [-::u]["1"]Reveal[::-] [green::bi]OFF[-::-][""]`
 )
 
 func (l *LogView) populateMenu() {
@@ -57,7 +62,7 @@ func (l *LogView) populateMenu() {
 
 	l.navMenu = tview.NewFlex().SetDirection(tview.FlexRow)
 	l.navMenu.
-		SetBackgroundColor(color.ColorBackgroundField).SetBorderPadding(0, 0, 0, 0)
+		SetBackgroundColor(l.app.Theme().Background).SetBorderPadding(0, 0, 0, 0)
 	sepForeground := tview.Styles.ContrastBackgroundColor
 	sepStyle := tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(sepForeground)
 	l.navMenu.
@@ -75,11 +80,28 @@ func (l *LogView) populateMenu() {
 				l.makeLayoutsWithTemplateView()
 			}
 		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(presetMenu), func() {
+			l.showPresets()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(timezoneMenu), func() {
+			l.showTimezones()
+		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().
 			SetDynamicColors(true).SetRegions(true).
 			SetText(localFilterMenu), func() {
 			l.toggleFilter()
-		}), 1, 2, false).
+		}), 1, 2, false)
+	if l.redactor != nil && l.redactor.AllowReveal() {
+		l.revealView = tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(revealOffMenu)
+		l.navMenu.AddItem(l.textViewMenuControl(l.revealView, l.toggleReveal), 1, 2, false)
+	}
+	l.navMenu.
 		//////////////////////////////////////////////////////////////////
 		// Navigation Menu
 		//////////////////////////////////////////////////////////////////
@@ -125,6 +147,12 @@ func (l *LogView) populateMenu() {
 	l.navMenu.
 		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "Selection", sepForeground), 1, 2, false).
 		AddItem(l.textViewMenuControl(l.mouseSel, l.toggleSelectionMouse), 1, 2, false)
+	//////////////////////////////////////////////////////////////////
+	// Severity Menu
+	//////////////////////////////////////////////////////////////////
+	l.navMenu.
+		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "Severity", sepForeground), 1, 2, false).
+		AddItem(l.severityView, 4, 2, false)
 	if runtime.GOOS != "windows" {
 		l.navMenu.
 			AddItem(tview.NewTextView().
@@ -154,7 +182,7 @@ func (l *LogView) populateMenu() {
 
 func (l *LogView) updateBottomBarMenu() {
 	l.mainMenu.Clear().
-		SetBackgroundColor(color.ColorBackgroundField).SetTitleAlign(tview.AlignCenter)
+		SetBackgroundColor(l.app.Theme().Background).SetTitleAlign(tview.AlignCenter)
 	l.mainMenu.
 		AddItem(l.textViewMenuControl(tview.NewTextView().
 			SetDynamicColors(true).SetRegions(true).
@@ -233,6 +261,23 @@ func (l *LogView) updateLineView() {
 	} else {
 		l.followingView.SetText(autoScrollOffMenu)
 	}
+	l.updateSeverityView()
+}
+
+// updateSeverityView shows how many of the filtered lines there are per canonical
+// level, two levels a line.
+func (l *LogView) updateSeverityView() {
+	var sb strings.Builder
+	for i, s := range config.Severities() {
+		if i%2 == 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(fmt.Sprintf("[%s::b]%-6s[-::-]%-6d", s.GetColorName(), s, l.severityCounts[s]))
+		if i%2 == 1 {
+			sb.WriteString("\n")
+		}
+	}
+	l.severityView.SetText(sb.String())
 }
 
 func (l *LogView) toggleSelectionMouse() {
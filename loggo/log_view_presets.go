@@ -0,0 +1,157 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jimbertools/loggo/config"
+	"github.com/rivo/tview"
+)
+
+const (
+	// presetSampleSize is how many records are fingerprinted to pick a preset.
+	presetSampleSize = 20
+	// presetSampleWait is how long to wait for presetSampleSize records before picking
+	// a preset out of fewer.
+	presetSampleWait = 2 * time.Second
+)
+
+// autoSelectPreset switches to the preset fitting the first records, if there's no
+// template and no preset was looked for yet.
+func (l *LogView) autoSelectPreset() {
+	l.presetLock.Lock()
+	defer l.presetLock.Unlock()
+	if l.presetChecked || l.config.HasTemplate() || l.config.NoAutoPreset || l.isTemplateViewShown() {
+		return
+	}
+	sample := l.presetSample()
+	if len(sample) == 0 {
+		return
+	}
+	l.presetChecked = true
+	name, ok := config.BestPreset(sample)
+	if !ok {
+		return
+	}
+	if err := l.applyPreset(name); err != nil {
+		return
+	}
+	l.app.ShowPopMessage(fmt.Sprintf("Laid out with the %s preset, ^p to change", name), 3, l.table)
+}
+
+// presetSample returns the first records, as fingerprinted to pick a preset.
+func (l *LogView) presetSample() []map[string]interface{} {
+	l.filterLock.RLock()
+	defer l.filterLock.RUnlock()
+	return append([]map[string]interface{}(nil), l.inSlice[:min(len(l.inSlice), presetSampleSize)]...)
+}
+
+// applyPreset lays the logs out with the named preset, or derives the keys from the
+// logs again if name is empty. The parser, the redaction rules and the timezone of the
+// current config are kept.
+func (l *LogView) applyPreset(name string) error {
+	cfg := &config.Config{NoAutoPreset: true}
+	if len(name) > 0 {
+		var err error
+		if cfg, err = config.MakeBuiltinConfig(name); err != nil {
+			return err
+		}
+	}
+	cfg.Parser = l.config.Parser
+	cfg.Multiline = l.config.Multiline
+	cfg.Redaction = l.config.Redaction
+	cfg.Timezone = l.config.Timezone
+	l.filterLock.Lock()
+	defer l.filterLock.Unlock()
+	l.config = cfg
+	l.keyMap = cfg.KeyMap()
+	l.app.config = cfg
+	if len(name) == 0 {
+		l.sampleAndCount()
+	}
+	return nil
+}
+
+// showPresets lists the presets to lay out the logs with, the ones fitting the first
+// records coming first, along with how many of them they fit.
+func (l *LogView) showPresets() {
+	sample := l.presetSample()
+	matches := config.MatchPresets(sample)
+	fits := make(map[string]config.PresetMatch)
+	for _, m := range matches {
+		fits[m.Preset] = m
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBackgroundColor(tcell.ColorDarkBlue).SetBorderPadding(0, 0, 1, 1)
+	addItem := func(name, text string) {
+		if name == l.config.Preset && len(l.config.LastSavedName) == 0 {
+			text = "[yellow::b]" + text
+		}
+		list.AddItem(text, "", 0, func() {
+			l.app.DismissModal(l.table)
+			if err := l.applyPreset(name); err != nil {
+				l.app.ShowPopMessage(err.Error(), 3, l.table)
+			}
+		})
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Preset)
+	}
+	for _, p := range config.Presets() {
+		if _, ok := fits[p.Name]; !ok {
+			names = append(names, p.Name)
+		}
+	}
+	for _, name := range names {
+		text := fmt.Sprintf("%-11s %s", name, presetDescription(name))
+		if m, ok := fits[name]; ok {
+			text += fmt.Sprintf(" [green](%d/%d records)", m.Records, len(sample))
+		}
+		addItem(name, text)
+	}
+	addItem("", fmt.Sprintf("%-11s %s", "none", "Keys derived from the logs"))
+
+	l.app.ShowModal(list, 70, len(names)+3, tcell.ColorDarkBlue,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEsc {
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			return event
+		})
+	l.app.SetFocus(list)
+}
+
+func presetDescription(name string) string {
+	for _, p := range config.Presets() {
+		if p.Name == name {
+			return p.Description
+		}
+	}
+	return ""
+}
@@ -23,7 +23,6 @@ THE SOFTWARE.
 package loggo
 
 import (
-	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -31,6 +30,8 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/jimbertools/loggo/config"
 	"github.com/jimbertools/loggo/filter"
+	"github.com/jimbertools/loggo/parser"
+	"github.com/jimbertools/loggo/reader"
 	"github.com/rivo/tview"
 )
 
@@ -42,29 +43,27 @@ var bytePool = sync.Pool{
 
 func (l *LogView) read() {
 	go func() {
+		// Checked upfront, so that every source can get its own parser later on
+		parserConfig := l.config.Parser
+		if _, err := parser.MakeParser(parserConfig); err != nil {
+			l.showFatal(fmt.Sprintf("Unable to parse logs: %v", err))
+			return
+		}
+		parsers := make(map[string]parser.Parser)
+		if l.redactErr != nil {
+			l.showFatal(fmt.Sprintf("Unable to redact logs: %v", l.redactErr))
+			return
+		}
+
 		if err := l.chanReader.StreamInto(); err != nil {
-			l.app.ShowPrefabModal(fmt.Sprintf("Unable to start stream: %v", err), 40, 10,
-				func(event *tcell.EventKey) *tcell.EventKey {
-					switch event.Key() {
-					case tcell.KeyEnter, tcell.KeyEsc:
-						l.app.Stop()
-						return nil
-					}
-					switch event.Rune() {
-					case 'Q', 'q':
-						l.app.Stop()
-						return nil
-					}
-					return event
-				},
-				tview.NewButton("[darkred::bu]Q[-::-]uit").SetSelectedFunc(func() {
-					l.app.Stop()
-				}))
+			l.showFatal(fmt.Sprintf("Unable to start stream: %v", err))
 			return
 		}
 
-		if len(l.config.LastSavedName) > 0 {
+		if l.config.HasTemplate() {
 			l.keyMap = l.config.KeyMap()
+		} else {
+			time.AfterFunc(presetSampleWait, l.autoSelectPreset)
 		}
 
 		// Set initial following state
@@ -72,20 +71,43 @@ func (l *LogView) read() {
 		l.updateLineView()
 
 		// Process logs line by line
-		for data := range l.chanReader.ChanReader() {
-			if len(data) == 0 {
+		for rec := range l.chanReader.Records() {
+			if len(rec.Text) == 0 {
 				continue
 			}
 
 			// Get a buffer from pool and copy data
 			buf := bytePool.Get().([]byte)
-			buf = append(buf[:0], data...) // Reset and copy
+			buf = append(buf[:0], rec.Text...) // Reset and copy
 
 			// Parse the log line
-			m := make(map[string]interface{})
-			if err := json.Unmarshal(buf, &m); err != nil {
-				m[config.ParseErr] = err.Error()
-				m[config.TextPayload] = string(buf) // Only convert to string when needed
+			p, ok := parsers[rec.Source]
+			if !ok {
+				p, _ = parser.MakeParser(parserConfig)
+				parsers[rec.Source] = p
+			}
+			m, err := parser.Parse(p, buf)
+			if err != nil {
+				m = map[string]interface{}{
+					config.ParseErr:    err.Error(),
+					config.TextPayload: string(buf), // Only convert to string when needed
+				}
+			} else if m == nil {
+				// Nothing to show, such as a header row
+				bytePool.Put(buf)
+				continue
+			}
+			if len(rec.Source) > 0 {
+				m[config.Source] = rec.Source
+			}
+			for k, v := range rec.Meta {
+				m[k] = v
+			}
+			if s := l.config.SeverityOf(m); s != config.SeverityNone {
+				m[config.Level] = s.String()
+			}
+			if l.redactor != nil {
+				m = l.redactor.Redact(m)
 			}
 
 			// Return buffer to pool
@@ -99,6 +121,10 @@ func (l *LogView) read() {
 			// Apply filter
 			l.filterLine(l.currentFilter, len(l.inSlice)-1)
 
+			if len(l.inSlice) == presetSampleSize {
+				l.autoSelectPreset()
+			}
+
 			// Batch UI updates
 			if l.isFollowing && len(l.inSlice)%10 == 0 { // Update every 10 lines
 				l.app.app.QueueUpdate(func() {
@@ -106,14 +132,47 @@ func (l *LogView) read() {
 				})
 			}
 		}
+		// The stream ended before enough records were sampled.
+		l.autoSelectPreset()
 	}()
 }
 
+// showFatal tells why the stream can't be shown, leaving quitting as the only option.
+func (l *LogView) showFatal(msg string) {
+	l.app.ShowPrefabModal(msg, 40, 10,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEnter, tcell.KeyEsc:
+				l.app.Stop()
+				return nil
+			}
+			switch event.Rune() {
+			case 'Q', 'q':
+				l.app.Stop()
+				return nil
+			}
+			return event
+		},
+		tview.NewButton("[darkred::bu]Q[-::-]uit").SetSelectedFunc(func() {
+			l.app.Stop()
+		}))
+}
+
+// showStreamEvent pops up a short notice about files joining or leaving the stream.
+func (l *LogView) showStreamEvent(e reader.Event) {
+	l.app.ShowPopMessage(e.String(), 2, l.table)
+}
+
 func (l *LogView) processSampleForConfig(sampling []map[string]interface{}) {
-	if len(l.config.LastSavedName) > 0 || l.isTemplateViewShown() {
+	if l.config.HasTemplate() || l.isTemplateViewShown() {
 		return
 	}
+	prev := l.config
 	l.config, l.keyMap = config.MakeConfigFromSample(sampling, l.config.Keys...)
+	l.config.Parser = prev.Parser
+	l.config.Redaction = prev.Redaction
+	l.config.Timezone = prev.Timezone
+	l.config.NoAutoPreset = prev.NoAutoPreset
 	l.app.config = l.config
 }
 
@@ -159,10 +218,11 @@ func (l *LogView) clearFilterBuffer() {
 	l.filterLock.Lock()
 	defer l.filterLock.Unlock()
 	l.finSlice = l.finSlice[:0]
+	l.severityCounts = [config.SeverityFatal + 1]int64{}
 }
 
 func (l *LogView) sampleAndCount() {
-	if len(l.config.LastSavedName) == 0 {
+	if !l.config.HasTemplate() {
 		if len(l.finSlice) > 20 {
 			l.processSampleForConfig(l.finSlice[len(l.finSlice)-20:])
 		} else {
@@ -172,6 +232,13 @@ func (l *LogView) sampleAndCount() {
 	l.updateLineView()
 }
 
+// countSeverity counts row by its canonical level, for the level statistics.
+func (l *LogView) countSeverity(row map[string]interface{}) {
+	if level, ok := row[config.Level].(string); ok {
+		l.severityCounts[config.ParseSeverity(level)]++
+	}
+}
+
 func (l *LogView) filterLine(e *filter.Expression, index int) error {
 	l.filterLock.Lock()
 	defer l.filterLock.Unlock()
@@ -179,6 +246,7 @@ func (l *LogView) filterLine(e *filter.Expression, index int) error {
 	if e == nil {
 		l.finSlice = append(l.finSlice, row)
 		l.globalCount++
+		l.countSeverity(row)
 		l.sampleAndCount()
 		return nil
 	}
@@ -209,6 +277,7 @@ func (l *LogView) filterLine(e *filter.Expression, index int) error {
 	if a {
 		l.finSlice = append(l.finSlice, row)
 		l.globalCount++
+		l.countSeverity(row)
 		l.sampleAndCount()
 	}
 	return nil
@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"github.com/jimbertools/loggo/config"
+)
+
+// shown returns the record to show for row: the raw one while revealing, if it was
+// kept, or else row as redacted.
+func (l *LogView) shown(row map[string]interface{}) map[string]interface{} {
+	if l.reveal {
+		return config.Reveal(row)
+	}
+	return row
+}
+
+// toggleReveal switches between showing the redacted values and the raw ones, which
+// is only possible if the redaction allows it, see config.Redaction.AllowReveal.
+func (l *LogView) toggleReveal() {
+	if l.redactor == nil {
+		return
+	}
+	if !l.redactor.AllowReveal() {
+		l.app.ShowPopMessage("Revealing redacted values isn't allowed", 2, l.table)
+		return
+	}
+	l.reveal = !l.reveal
+	if l.reveal {
+		l.revealView.SetText(revealOnMenu)
+		l.app.ShowPopMessage("Showing raw values, redacted ones included", 2, l.table)
+	} else {
+		// An entry opened while revealing would keep showing its raw values.
+		if l.isJsonViewShown() {
+			l.makeLayouts()
+		}
+		l.revealView.SetText(revealOffMenu)
+		l.app.ShowPopMessage("Redacted values are masked again", 2, l.table)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/jimbertools/loggo/color"
 	"github.com/jimbertools/loggo/config"
 	"github.com/rivo/tview"
 )
@@ -59,7 +60,7 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 				return tc
 			} else {
 				tc := tview.NewTableCell(fmt.Sprintf("%d ", row)).
-					SetTextColor(tcell.ColorYellow).
+					SetTextColor(d.lineColor(d.logView.finSlice[row-1])).
 					SetAlign(tview.AlignRight).
 					SetBackgroundColor(tcell.ColorBlack)
 				return tc
@@ -85,24 +86,24 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 		return tc
 	}
 	// Set Body Cells
-	cellValue := k.ExtractValue(d.logView.finSlice[row-1])
+	cellValue := k.ExtractValue(d.logView.shown(d.logView.finSlice[row-1]))
 	var bgColor, fgColor tcell.Color
-	if len(k.Color.Foreground) == 0 {
+	if len(k.Color.Foreground) == 0 && k.Name == config.Source {
+		fgColor = color.ForValue(cellValue)
+	} else if len(k.Color.Foreground) == 0 {
 		fgColor = k.Type.GetColor()
 	} else {
 		fgColor = k.Color.GetForegroundColor()
 	}
 	bgColor = k.Color.GetBackgroundColor()
-	if len(k.ColorWhen) > 0 {
-	OUT:
-		for _, kv := range k.ColorWhen {
-			reg, err := regexp.Compile(kv.MatchValue)
-			if err == nil && reg.FindIndex([]byte(cellValue)) != nil {
-				bgColor = kv.Color.GetBackgroundColor()
-				fgColor = kv.Color.GetForegroundColor()
-				break OUT
-			}
-		}
+	values := []string{cellValue}
+	// The level key is resolved as lineColor does, so that aliases fall back too.
+	if c.KeyFor(config.RoleLevel) == &c.Keys[column-1] {
+		values = append(values, canonicalLevel(d.logView.finSlice[row-1]))
+	}
+	if kv := colorWhen(&k, values...); kv != nil {
+		bgColor = kv.Color.GetBackgroundColor()
+		fgColor = kv.Color.GetForegroundColor()
 	}
 	switch k.Type {
 	case config.TypeNumber, config.TypeBool:
@@ -121,7 +122,49 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	return tc.
 		SetBackgroundColor(bgColor).
 		SetTextColor(fgColor).
-		SetText(fmt.Sprintf("%s", cellValue))
+		SetText(k.FormatTime(cellValue, d.logView.timezone.Load()))
+}
+
+// lineColor colours the line number of a row after the colour the level key gives
+// its level, whatever the key is called, so that rows stand out by level. Levels the
+// key doesn't colour get the colour of their canonical level.
+func (d *LogData) lineColor(row map[string]interface{}) tcell.Color {
+	c := d.logView.config
+	level := canonicalLevel(row)
+	if k := c.KeyFor(config.RoleLevel); k != nil {
+		if kv := colorWhen(k, k.ExtractValue(row), level); kv != nil {
+			// Levels highlighted by their background keep standing out on black.
+			if bg := kv.Color.GetBackgroundColor(); bg != tcell.ColorBlack {
+				return bg
+			}
+			return kv.Color.GetForegroundColor()
+		}
+	}
+	return tcell.GetColor(config.ParseSeverity(level).GetColorName())
+}
+
+// colorWhen returns the first ColorWhen of k matching the first of values it can, if
+// any. Values are tried in turn, e.g. the raw level and then the canonical one.
+func colorWhen(k *config.Key, values ...string) *config.ColorWhen {
+	for i, value := range values {
+		// Only the first value may be empty, the others being fallbacks.
+		if i > 0 && len(value) == 0 {
+			continue
+		}
+		for j, kv := range k.ColorWhen {
+			reg, err := regexp.Compile(kv.MatchValue)
+			if err == nil && reg.FindIndex([]byte(value)) != nil {
+				return &k.ColorWhen[j]
+			}
+		}
+	}
+	return nil
+}
+
+// canonicalLevel returns the level of row on the canonical scale, if it has one.
+func canonicalLevel(row map[string]interface{}) string {
+	level, _ := row[config.Level].(string)
+	return level
 }
 
 func (d *LogData) GetRowCount() int {
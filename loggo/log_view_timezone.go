@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jimbertools/loggo/config"
+	"github.com/rivo/tview"
+)
+
+// setTimezone shows datetime keys in the timezone called name, see config.LoadTimezone.
+// It's kept in the config, for the template to be saved with it.
+func (l *LogView) setTimezone(name string) error {
+	loc, err := config.LoadTimezone(name)
+	if err != nil {
+		return err
+	}
+	l.timezone.Store(loc)
+	l.filterLock.Lock()
+	l.config.Timezone = name
+	l.filterLock.Unlock()
+	return nil
+}
+
+// showTimezones lists the timezones to show datetime keys in, the current one being
+// highlighted. Any other can be typed in by its IANA name.
+func (l *LogView) showTimezones() {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBackgroundColor(tcell.ColorDarkBlue).SetBorderPadding(0, 0, 1, 1)
+	current := strings.ToLower(l.config.Timezone)
+	addItem := func(name, text string) {
+		if strings.ToLower(name) == current {
+			text = "[yellow::b]" + text
+		}
+		list.AddItem(text, "", 0, func() {
+			l.app.DismissModal(l.table)
+			if err := l.setTimezone(name); err != nil {
+				l.app.ShowPopMessage(err.Error(), 3, l.table)
+			}
+		})
+	}
+	addItem("", "As logged")
+	addItem(config.TimezoneLocal, fmt.Sprintf("Local (%s)", time.Now().Location()))
+	addItem(config.TimezoneUTC, "UTC")
+	if _, err := config.LoadTimezone(current); err == nil && current != "" &&
+		current != config.TimezoneLocal && current != config.TimezoneUTC {
+		addItem(l.config.Timezone, l.config.Timezone)
+	}
+	list.AddItem("Other...", "", 0, l.showTimezoneInput)
+
+	l.app.ShowModal(list, 40, list.GetItemCount()+2, tcell.ColorDarkBlue,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEsc {
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			return event
+		})
+	l.app.SetFocus(list)
+}
+
+// showTimezoneInput asks for the IANA name of the timezone to show datetime keys in,
+// such as Europe/Brussels.
+func (l *LogView) showTimezoneInput() {
+	input := tview.NewInputField().
+		SetLabel("Timezone ").
+		SetPlaceholder("e.g. Europe/Brussels").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorBlack)
+	input.SetBackgroundColor(tcell.ColorDarkBlue).SetBorderPadding(0, 0, 1, 1)
+	input.SetDoneFunc(func(key tcell.Key) {
+		l.app.DismissModal(l.table)
+		if key != tcell.KeyEnter {
+			return
+		}
+		if err := l.setTimezone(strings.TrimSpace(input.GetText())); err != nil {
+			l.app.ShowPopMessage(err.Error(), 3, l.table)
+		}
+	})
+	l.app.ShowModal(input, 50, 3, tcell.ColorDarkBlue, nil)
+	l.app.SetFocus(input)
+}
@@ -28,7 +28,6 @@ import (
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/jimbertools/loggo/color"
 	"github.com/jimbertools/loggo/config"
 	"github.com/rivo/tview"
 )
@@ -70,7 +69,7 @@ func (t *TemplateItemView) makeUIComponents() {
 	t.contextMenu.
 		SetBorder(true).
 		SetTitle("Context Menu").
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(t.app.Theme().Background)
 
 	// Main Form
 	// text color
@@ -94,7 +93,7 @@ func (t *TemplateItemView) makeUIComponents() {
 	//selectType
 	typeDD := tview.NewDropDown().
 		SetLabel("Type [red]*").
-		SetListStyles(color.FieldStyle, color.SelectStyle).
+		SetListStyles(t.app.Theme().FieldStyle(), t.app.Theme().SelectStyle()).
 		AddOption(config.TypeString+"  ", nil).
 		AddOption(config.TypeDateTime+"  ", nil).
 		AddOption(config.TypeBool+"  ", nil).
@@ -119,6 +118,30 @@ func (t *TemplateItemView) makeUIComponents() {
 	}
 	typeDD.SetCurrentOption(currOpt)
 
+	roleDD := tview.NewDropDown().
+		SetLabel("Role").
+		SetListStyles(t.app.Theme().FieldStyle(), t.app.Theme().SelectStyle()).
+		AddOption("none  ", nil)
+	currRole := 0
+	for i, role := range config.Roles() {
+		roleDD.AddOption(string(role)+"  ", nil)
+		if role == t.key.Role {
+			currRole = i + 1
+		}
+	}
+	roleDD.SetSelectedFunc(func(text string, index int) {
+		t.key.Role = config.RoleNone
+		if index > 0 {
+			t.key.Role = config.Roles()[index-1]
+		}
+	})
+	roleDD.SetCurrentOption(currRole)
+
+	derive := &config.Derive{}
+	if t.key.Derive != nil {
+		derive = t.key.Derive
+	}
+
 	t.form = tview.NewForm().
 		SetFieldBackgroundColor(tcell.ColorDarkGray).
 		SetFieldTextColor(tcell.ColorBlack).
@@ -126,9 +149,30 @@ func (t *TemplateItemView) makeUIComponents() {
 			t.key.Name = strings.TrimSpace(text)
 		}).
 		AddFormItem(typeDD).
+		AddFormItem(roleDD).
 		AddInputField("Layout", t.key.Layout, maxFieldWidth, nil, func(text string) {
 			t.key.Layout = strings.TrimSpace(text)
 		}).
+		AddInputField("More Layouts (;)", strings.Join(t.key.Layouts, "; "), maxFieldWidth, nil, func(text string) {
+			t.key.Layouts = nil
+			for _, layout := range strings.Split(text, ";") {
+				if layout = strings.TrimSpace(layout); len(layout) > 0 {
+					t.key.Layouts = append(t.key.Layouts, layout)
+				}
+			}
+		}).
+		AddInputField("Display Layout", t.key.DisplayLayout, maxFieldWidth, nil, func(text string) {
+			t.key.DisplayLayout = strings.TrimSpace(text)
+		}).
+		AddInputField("Derive Expression", derive.Expression, maxFieldWidth, nil, func(text string) {
+			t.setDerive(func(d *config.Derive) { d.Expression = strings.TrimSpace(text) })
+		}).
+		AddInputField("Derive From", derive.From, maxFieldWidth, nil, func(text string) {
+			t.setDerive(func(d *config.Derive) { d.From = strings.TrimSpace(text) })
+		}).
+		AddInputField("Derive Regex", derive.Regex, maxFieldWidth, nil, func(text string) {
+			t.setDerive(func(d *config.Derive) { d.Regex = strings.TrimSpace(text) })
+		}).
 		AddFormItem(textColor).
 		AddFormItem(textBgColor).
 		AddInputField("Max Width", fmt.Sprintf("%d", t.key.MaxWidth), maxFieldWidth,
@@ -154,6 +198,22 @@ func (t *TemplateItemView) makeUIComponents() {
 	t.form.SetFocus(0)
 }
 
+// setDerive updates how the key is derived, the key being no longer derived once
+// every field of the derivation is cleared.
+func (t *TemplateItemView) setDerive(update func(d *config.Derive)) {
+	d := config.Derive{}
+	if t.key.Derive != nil {
+		d = config.Derive{Expression: t.key.Derive.Expression, From: t.key.Derive.From, Regex: t.key.Derive.Regex}
+	}
+	update(&d)
+	t.key.Derive = nil
+	if d != (config.Derive{}) {
+		// Invalid derivations have no value, but they are parsed once either way.
+		_ = d.Validate()
+		t.key.Derive = &d
+	}
+}
+
 func (t *TemplateItemView) makeCaseWhenForm() {
 	// Case When Form
 	caseWhenColorable := func() *config.Color {
@@ -225,7 +285,7 @@ func (t *TemplateItemView) makeLayouts() {
 	t.Flex.Clear().SetDirection(tview.FlexRow).
 		//AddItem(t.contextMenu, 3, 1, false).
 		AddItem(formLayout, 0, 2, true).
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(t.app.Theme().Background)
 
 	t.makeCaseWhenData()
 }
@@ -29,7 +29,6 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/jimbertools/loggo/color"
 	"github.com/jimbertools/loggo/config"
 	"github.com/rivo/tview"
 )
@@ -134,7 +133,7 @@ func (t *TemplateView) makeUIComponents() {
 	t.contextMenu.
 		SetBorder(true).
 		SetTitle("Context Menu").
-		SetBackgroundColor(color.ColorBackgroundField)
+		SetBackgroundColor(t.app.Theme().Background)
 }
 
 func (t *TemplateView) makeLayouts() {
@@ -142,8 +141,8 @@ func (t *TemplateView) makeLayouts() {
 	t.Flex.Clear().SetDirection(tview.FlexColumn).
 		AddItem(t.contextMenu, 30, 1, false).
 		AddItem(t.table, 0, 2, true).
-		SetBackgroundColor(color.ColorBackgroundField)
-	go t.app.SetFocus(t.contextMenu)
+		SetBackgroundColor(t.app.Theme().Background)
+	t.app.SetFocus(t.contextMenu)
 }
 
 func (t *TemplateView) makeSaveLayouts() {
@@ -162,7 +161,7 @@ func (t *TemplateView) makeSaveUI() (*tview.Flex, *tview.InputField) {
 		dirName = fmt.Sprintf(`%s%c`, dirName, os.PathSeparator)
 	}
 	saveBar := tview.NewFlex().SetDirection(tview.FlexColumn)
-	saveBar.SetBackgroundColor(color.ColorBackgroundField).SetBorder(true).SetTitle("Save Template As...")
+	saveBar.SetBackgroundColor(t.app.Theme().Background).SetBorder(true).SetTitle("Save Template As...")
 
 	saveInput := tview.NewInputField().SetText(dirName)
 	saveInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -415,7 +414,11 @@ func (d *TemplateData) GetCell(row, column int) *tview.TableCell {
 	var cell *tview.TableCell
 	switch column {
 	case 0:
-		cell = tview.NewTableCell(" " + k.Name + " ")
+		name := " " + k.Name + " "
+		if k.Derive != nil {
+			name += "[::d](derived)[::-] "
+		}
+		cell = tview.NewTableCell(name)
 	case 1:
 		cell = tview.NewTableCell(" " + string(k.Type) + " ").
 			SetTextColor(k.Type.GetColor()).
@@ -0,0 +1,87 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// combinedLine matches the Apache/Nginx combined access log format, as well as the
+// common log format it extends, i.e. without referer and user agent:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/4.08"
+var combinedLine = regexp.MustCompile(`^(?P<remoteAddr>\S+) (?P<ident>\S+) (?P<remoteUser>\S+) ` +
+	`\[(?P<time>[^\]]+)\] "(?P<request>(?:[^"\\]|\\.)*)" (?P<status>\d{3}|-) (?P<bytes>\d+|-)` +
+	`(?: "(?P<referer>(?:[^"\\]|\\.)*)" "(?P<userAgent>(?:[^"\\]|\\.)*)")?`)
+
+var requestLine = regexp.MustCompile(`^(\S+) (\S+)(?: (\S+))?$`)
+
+const accessLogLayout = "02/Jan/2006:15:04:05 -0700"
+
+// combinedParser reads access logs. The request is also split into its method, path
+// and protocol, the time is converted into a timestamp and the status and bytes
+// into numbers. Placeholder values, i.e. "-", are left out.
+type combinedParser struct {
+	regexParser
+}
+
+func makeCombinedParser() *combinedParser {
+	return &combinedParser{regexParser{re: combinedLine}}
+}
+
+func (p *combinedParser) Parse(line []byte) (map[string]interface{}, error) {
+	m, err := p.regexParser.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		if v == "-" || v == "" {
+			delete(m, k)
+		}
+	}
+	if req, ok := m["request"].(string); ok {
+		if parts := requestLine.FindStringSubmatch(req); parts != nil {
+			m["method"] = parts[1]
+			m["path"] = parts[2]
+			if len(parts[3]) > 0 {
+				m["protocol"] = parts[3]
+			}
+		}
+	}
+	if t, ok := m["time"].(string); ok {
+		if ts, err := time.Parse(accessLogLayout, t); err == nil {
+			m["timestamp"] = ts.Format(time.RFC3339)
+			delete(m, "time")
+		}
+	}
+	for _, k := range []string{"status", "bytes"} {
+		if v, ok := m[k].(string); ok {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				m[k] = n
+			}
+		}
+	}
+	return m, nil
+}
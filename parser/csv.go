@@ -0,0 +1,80 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// csvParser reads a record per line, naming its fields after the configured columns
+// or else after the header row, i.e. the first line it's given.
+type csvParser struct {
+	columns []string
+	comma   rune
+}
+
+func makeCSVParser(columns []string, separator string) (*csvParser, error) {
+	p := &csvParser{
+		columns: columns,
+		comma:   ',',
+	}
+	if len(separator) > 0 {
+		if separator == `\t` {
+			separator = "\t"
+		}
+		r, size := utf8.DecodeRuneInString(separator)
+		if size != len(separator) || r == '"' || r == '\r' || r == '\n' {
+			return nil, fmt.Errorf("invalid csv separator %q", separator)
+		}
+		p.comma = r
+	}
+	return p, nil
+}
+
+func (p *csvParser) Parse(line []byte) (map[string]interface{}, error) {
+	r := csv.NewReader(strings.NewReader(string(line)))
+	r.Comma = p.comma
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	if p.columns == nil {
+		p.columns = make([]string, len(fields))
+		for i, f := range fields {
+			p.columns[i] = strings.TrimSpace(strings.TrimPrefix(f, "\ufeff"))
+		}
+		return nil, nil
+	}
+	if len(fields) > len(p.columns) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(p.columns), len(fields))
+	}
+	m := make(map[string]interface{}, len(fields))
+	for i, f := range fields {
+		m[p.columns[i]] = f
+	}
+	return m, nil
+}
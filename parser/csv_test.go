@@ -0,0 +1,74 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCsvParser_Parse(t *testing.T) {
+	tests := []struct {
+		name      string
+		columns   []string
+		separator string
+		given     []string
+		wants     []map[string]interface{}
+		wantsErr  []bool
+	}{
+		{
+			name:  "Header row",
+			given: []string{"\ufefftime,level, msg", `2026-10-01,info,"hello, world"`, `2026-10-02,warn`},
+			wants: []map[string]interface{}{
+				nil,
+				{"time": "2026-10-01", "level": "info", "msg": "hello, world"},
+				{"time": "2026-10-02", "level": "warn"},
+			},
+			wantsErr: []bool{false, false, false},
+		},
+		{
+			name:      "Given columns",
+			columns:   []string{"level", "msg"},
+			separator: `\t`,
+			given:     []string{"error\tboom", "info\tok\textra"},
+			wants:     []map[string]interface{}{{"level": "error", "msg": "boom"}, nil},
+			wantsErr:  []bool{false, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := makeCSVParser(test.columns, test.separator)
+			assert.NoError(t, err)
+			for i, line := range test.given {
+				m, err := p.Parse([]byte(line))
+				if test.wantsErr[i] {
+					assert.Error(t, err)
+					continue
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, test.wants[i], m)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	parentPath   = ".loggo"
+	patternsPath = "patterns"
+	// maxGrokDepth bounds how deep patterns may refer to one another, which also stops
+	// patterns referring to themselves.
+	maxGrokDepth = 32
+)
+
+// grokRef matches %{NAME}, %{NAME:field} and %{NAME:field:type} pattern references.
+var grokRef = regexp.MustCompile(`%\{(\w+)(?::([^:}]+))?(?::(int|float))?\}`)
+
+type grokField struct {
+	name string
+	// conversion is either int, float or empty, to keep the text.
+	conversion string
+}
+
+// grokParser takes the fields of a line from the named references of its grok
+// expression, e.g. %{LOGLEVEL:level}.
+type grokParser struct {
+	re *regexp.Regexp
+	// fields are keyed by the name of their capture group, as field names needn't be
+	// valid group names.
+	fields map[string]grokField
+}
+
+func makeGrokParser(expression string, patterns map[string]string) (*grokParser, error) {
+	if len(expression) == 0 {
+		return nil, errors.New("grok parser requires a pattern")
+	}
+	library, err := grokLibrary(patterns)
+	if err != nil {
+		return nil, err
+	}
+	p := &grokParser{fields: make(map[string]grokField)}
+	expanded, err := p.expand(expression, library, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.fields) == 0 {
+		return nil, fmt.Errorf("grok pattern %s names no fields, e.g. %%{LOGLEVEL:level}", expression)
+	}
+	if p.re, err = regexp.Compile(expanded); err != nil {
+		return nil, fmt.Errorf("invalid grok pattern: %w", err)
+	}
+	return p, nil
+}
+
+// expand replaces the pattern references in expression by their definitions, turning
+// the ones naming a field into capture groups.
+func (p *grokParser) expand(expression string, library map[string]string, depth int) (string, error) {
+	if depth > maxGrokDepth {
+		return "", fmt.Errorf("grok patterns nested too deep, possibly recursive: %s", expression)
+	}
+	var err error
+	expanded := grokRef.ReplaceAllStringFunc(expression, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		parts := grokRef.FindStringSubmatch(ref)
+		def, ok := library[parts[1]]
+		if !ok {
+			err = fmt.Errorf("unknown grok pattern %s", parts[1])
+			return ""
+		}
+		var sub string
+		if sub, err = p.expand(def, library, depth+1); err != nil {
+			return ""
+		}
+		if len(parts[2]) == 0 {
+			return "(?:" + sub + ")"
+		}
+		group := "g" + strconv.Itoa(len(p.fields))
+		p.fields[group] = grokField{name: parts[2], conversion: parts[3]}
+		return "(?P<" + group + ">" + sub + ")"
+	})
+	return expanded, err
+}
+
+func (p *grokParser) Parse(line []byte) (map[string]interface{}, error) {
+	match := p.re.FindSubmatchIndex(line)
+	if match == nil {
+		return nil, errNoMatch
+	}
+	m := make(map[string]interface{})
+	for i, group := range p.re.SubexpNames() {
+		f, ok := p.fields[group]
+		if !ok || match[2*i] < 0 {
+			continue
+		}
+		// The same field may be named more than once, in alternatives for instance
+		if _, ok := m[f.name]; ok {
+			continue
+		}
+		v := string(line[match[2*i]:match[2*i+1]])
+		m[f.name] = v
+		switch f.conversion {
+		case "int":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				m[f.name] = json.Number(strconv.FormatInt(n, 10))
+			}
+		case "float":
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				m[f.name] = n
+			}
+		}
+	}
+	return m, nil
+}
+
+// grokLibrary gathers the patterns references can be made to: the bundled ones,
+// overridden by the user's, overridden in turn by the template's.
+func grokLibrary(patterns map[string]string) (map[string]string, error) {
+	library := make(map[string]string)
+	if err := readGrokPatterns(strings.NewReader(grokPatterns), library); err != nil {
+		return nil, err
+	}
+	if err := loadUserGrokPatterns(library); err != nil {
+		return nil, err
+	}
+	for name, def := range patterns {
+		library[name] = def
+	}
+	return library, nil
+}
+
+// loadUserGrokPatterns reads the patterns defined under ~/.loggo/patterns, which is
+// either a pattern file or a directory of them.
+func loadUserGrokPatterns(library map[string]string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	dir := path.Join(home, parentPath, patternsPath)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+	files := []string{dir}
+	if info.IsDir() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		files = files[:0]
+		for _, e := range entries {
+			if !e.IsDir() {
+				files = append(files, path.Join(dir, e.Name()))
+			}
+		}
+	}
+	for _, fileName := range files {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return err
+		}
+		err = readGrokPatterns(f, library)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("invalid grok patterns in %s: %w", fileName, err)
+		}
+	}
+	return nil
+}
+
+// readGrokPatterns adds the patterns read from r, one per line as its name followed
+// by its definition. Blank lines and lines starting with # are skipped.
+func readGrokPatterns(r io.Reader, library map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexFunc(line, unicode.IsSpace)
+		if i < 0 {
+			return fmt.Errorf("line %d: expected a name and a definition", n)
+		}
+		library[line[:i]] = strings.TrimSpace(line[i:])
+	}
+	return scanner.Err()
+}
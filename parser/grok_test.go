@@ -0,0 +1,139 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrokPatterns_Compile(t *testing.T) {
+	library := make(map[string]string)
+	assert.NoError(t, readGrokPatterns(strings.NewReader(grokPatterns), library))
+	for name := range library {
+		t.Run(name, func(t *testing.T) {
+			_, err := makeGrokParser("%{"+name+":value}", nil)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGrokParser_Parse(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		name       string
+		expression string
+		patterns   map[string]string
+		given      string
+		wants      map[string]interface{}
+		wantsErr   bool
+	}{
+		{
+			name:       "Java log",
+			expression: `%{TIMESTAMP_ISO8601:ts} %{LOGLEVEL:level} +\[%{DATA:thread}\] %{JAVACLASS:logger} - %{GREEDYDATA:msg}`,
+			given:      `2026-10-01 12:00:00,123 WARN  [main] com.acme.App - low disk`,
+			wants: map[string]interface{}{
+				"ts": "2026-10-01 12:00:00,123", "level": "WARN", "thread": "main",
+				"logger": "com.acme.App", "msg": "low disk",
+			},
+		},
+		{
+			name:       "Nginx error log",
+			expression: `%{NGINX_ERRORLOG}`,
+			given:      `2026/10/01 12:00:00 [error] 31#31: *7 open() "/x" failed (2: No such file or directory)`,
+			wants: map[string]interface{}{
+				"timestamp": "2026/10/01 12:00:00", "level": "error", "pid": "31", "tid": "31",
+				"connection": "7", "message": `open() "/x" failed (2: No such file or directory)`,
+			},
+		},
+		{
+			name:       "Conversions",
+			expression: `%{COMBINEDAPACHELOG}`,
+			given:      `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "-" "curl/8.0"`,
+			wants: map[string]interface{}{
+				"clientip": "127.0.0.1", "ident": "-", "auth": "frank", "timestamp": "10/Oct/2000:13:55:36 -0700",
+				"verb": "GET", "request": "/a.gif", "httpversion": "1.0", "response": json.Number("200"),
+				"bytes": json.Number("2326"), "referrer": `"-"`, "agent": `"curl/8.0"`,
+			},
+		},
+		{
+			name:       "Template patterns",
+			expression: `%{REQID:id} took %{NUMBER:ms:float}ms`,
+			patterns:   map[string]string{"REQID": `req-[0-9a-f]+`},
+			given:      `req-3f2a took 12.5ms`,
+			wants:      map[string]interface{}{"id": "req-3f2a", "ms": 12.5},
+		},
+		{
+			name:       "No match",
+			expression: `%{IPV4:ip}`,
+			given:      `no address`,
+			wantsErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := makeGrokParser(test.expression, test.patterns)
+			assert.NoError(t, err)
+			m, err := p.Parse([]byte(test.given))
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, m)
+		})
+	}
+}
+
+func TestMakeGrokParser(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := path.Join(home, parentPath, patternsPath)
+	assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	assert.NoError(t, os.WriteFile(path.Join(dir, "acme"),
+		[]byte("# Acme patterns\n\nACME_ID\tACME-%{INT}\nLOOP %{LOOP}\n"), 0644))
+
+	p, err := makeGrokParser(`%{ACME_ID:id}`, nil)
+	assert.NoError(t, err)
+	m, err := p.Parse([]byte(`order ACME-42 shipped`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "ACME-42"}, m)
+
+	_, err = makeGrokParser(`%{LOOP:x}`, nil)
+	assert.Error(t, err)
+	_, err = makeGrokParser(`%{NOPE:x}`, nil)
+	assert.Error(t, err)
+	_, err = makeGrokParser(`%{INT}`, nil)
+	assert.Error(t, err)
+	_, err = makeGrokParser(``, nil)
+	assert.Error(t, err)
+
+	assert.NoError(t, os.WriteFile(path.Join(dir, "bad"), []byte("BAD\n"), 0644))
+	_, err = makeGrokParser(`%{INT:x}`, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jimbertools/loggo/config"
+)
+
+var errNoJSONObject = errors.New("no JSON object found")
+
+// lenientJSONParser parses the first balanced JSON object found in a line, wherever it
+// starts. The text before it is split into fields by the named groups of the prefix
+// pattern, if given, or else kept whole as config.Prefix. Fields of the object win
+// over the prefix ones.
+type lenientJSONParser struct {
+	prefix *regexp.Regexp
+}
+
+func makeLenientJSONParser(prefix string) (*lenientJSONParser, error) {
+	p := &lenientJSONParser{}
+	if len(prefix) > 0 {
+		re, err := regexp.Compile(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prefix pattern: %w", err)
+		}
+		p.prefix = re
+	}
+	return p, nil
+}
+
+func (p *lenientJSONParser) Parse(line []byte) (map[string]interface{}, error) {
+	for i := 0; i < len(line); i++ {
+		if line[i] != '{' {
+			continue
+		}
+		end := objectEnd(line, i)
+		if end < 0 {
+			// Unbalanced to the end of the line, as any later object would be too.
+			break
+		}
+		m, err := DecodeJSON(line[i:end])
+		if err != nil || m == nil {
+			continue
+		}
+		p.addPrefix(m, strings.TrimSpace(string(line[:i])))
+		return m, nil
+	}
+	return nil, errNoJSONObject
+}
+
+// addPrefix adds the fields of prefix to m, without overriding the ones of the object.
+func (p *lenientJSONParser) addPrefix(m map[string]interface{}, prefix string) {
+	if len(prefix) == 0 {
+		return
+	}
+	if p.prefix != nil {
+		if match := p.prefix.FindStringSubmatch(prefix); match != nil {
+			for i, name := range p.prefix.SubexpNames() {
+				if _, ok := m[name]; !ok && len(name) > 0 && len(match[i]) > 0 {
+					m[name] = match[i]
+				}
+			}
+			return
+		}
+	}
+	m[config.Prefix] = prefix
+}
+
+// objectEnd returns the index right past the end of the object opening at start, or
+// -1 if it doesn't end within line.
+func objectEnd(line []byte, start int) int {
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(line); i++ {
+		c := line[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jimbertools/loggo/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLenientJSONParser_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		given    string
+		wants    map[string]interface{}
+		wantsErr bool
+	}{
+		{
+			name:   "Prefix split into fields",
+			prefix: `^(?P<time>\S+) (?P<level>\w+) \[(?P<thread>[^\]]+)\]`,
+			given:  `2026-10-01T12:00:00Z INFO [worker-3] {"event":"x","n":1}`,
+			wants: map[string]interface{}{
+				"time": "2026-10-01T12:00:00Z", "level": "INFO", "thread": "worker-3",
+				"event": "x", "n": json.Number("1"),
+			},
+		},
+		{
+			name:   "Object fields win",
+			prefix: `^(?P<level>\w+)`,
+			given:  `INFO {"level":"debug"}`,
+			wants:  map[string]interface{}{"level": "debug"},
+		},
+		{
+			name:   "Prefix not matching",
+			prefix: `^(?P<level>[A-Z]+):`,
+			given:  `12:00 worker {"event":"x"} trailing`,
+			wants:  map[string]interface{}{config.Prefix: "12:00 worker", "event": "x"},
+		},
+		{
+			name:  "Braces before the object",
+			given: `job {42} done: {"msg":"has } and { in it"}`,
+			wants: map[string]interface{}{config.Prefix: "job {42} done:", "msg": "has } and { in it"},
+		},
+		{
+			name:  "Plain JSON",
+			given: `{"a":"b"}`,
+			wants: map[string]interface{}{"a": "b"},
+		},
+		{name: "No object", given: `INFO started`, wantsErr: true},
+		{name: "Unbalanced object", given: `INFO {"a":`, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := makeLenientJSONParser(test.prefix)
+			assert.NoError(t, err)
+			m, err := p.Parse([]byte(test.given))
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, m)
+		})
+	}
+}
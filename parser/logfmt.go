@@ -0,0 +1,96 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// logfmtParser reads key=value pairs separated by spaces, where values may be double
+// quoted. Keys without a value are flags, set to true.
+type logfmtParser struct{}
+
+var errNoLogfmtPairs = errors.New("no key=value pairs found")
+
+func (p *logfmtParser) Parse(line []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	s := string(line)
+	pairs := 0
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' && s[i] != '\t' {
+			i++
+		}
+		key := s[start:i]
+		if i == len(s) || s[i] != '=' {
+			m[key] = true
+			continue
+		}
+		i++ // skip '='
+		if len(key) == 0 {
+			return nil, fmt.Errorf("missing key at column %d", start+1)
+		}
+		if i < len(s) && s[i] == '"' {
+			end, err := quotedEnd(s, i)
+			if err != nil {
+				return nil, err
+			}
+			v, err := strconv.Unquote(s[i:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", key, err)
+			}
+			m[key] = v
+			i = end
+		} else {
+			start = i
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+				i++
+			}
+			m[key] = s[start:i]
+		}
+		pairs++
+	}
+	if pairs == 0 {
+		return nil, errNoLogfmtPairs
+	}
+	return m, nil
+}
+
+// quotedEnd returns the index right past the double quoted string starting at i.
+func quotedEnd(s string, i int) (int, error) {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quote at column %d", i+1)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtParser_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    string
+		wants    map[string]interface{}
+		wantsErr bool
+	}{
+		{
+			name:  "Plain values",
+			given: `time=2022-10-01T12:00:00Z level=info msg=started`,
+			wants: map[string]interface{}{"time": "2022-10-01T12:00:00Z", "level": "info", "msg": "started"},
+		},
+		{
+			name:  "Quoted values",
+			given: `level=error msg="could not \"connect\"" err="dial tcp: timeout"`,
+			wants: map[string]interface{}{"level": "error", "msg": `could not "connect"`, "err": "dial tcp: timeout"},
+		},
+		{
+			name:  "Flags and empty values",
+			given: `  debug level= msg=x	`,
+			wants: map[string]interface{}{"debug": true, "level": "", "msg": "x"},
+		},
+		{name: "No pairs", given: `just some text`, wantsErr: true},
+		{name: "Unterminated quote", given: `msg="oops`, wantsErr: true},
+		{name: "Missing key", given: `a=b =c`, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := (&logfmtParser{}).Parse([]byte(test.given))
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, m)
+		})
+	}
+}
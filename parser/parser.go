@@ -0,0 +1,117 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package parser breaks log lines into the fields rendered by the log view.
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jimbertools/loggo/config"
+)
+
+const (
+	TypeJSON        = "json"
+	TypeLenientJSON = "lenient-json"
+	TypeLogfmt      = "logfmt"
+	TypeRegex       = "regex"
+	TypeCombined    = "combined"
+	TypeCSV         = "csv"
+	TypeGrok        = "grok"
+)
+
+// Parser turns a line into a record of fields, keyed by field name.
+type Parser interface {
+	// Parse returns the fields of line, or an error if line can't be parsed. A nil
+	// record without error means line carries no record, like a CSV header row.
+	Parse(line []byte) (map[string]interface{}, error)
+}
+
+// Types lists the available parser types.
+func Types() []string {
+	return []string{TypeJSON, TypeLenientJSON, TypeLogfmt, TypeRegex, TypeCombined, TypeCSV, TypeGrok}
+}
+
+// MakeParser builds the parser set up by c, defaulting to JSON when c is nil.
+// Parsers may keep state between lines, so each source needs its own.
+func MakeParser(c *config.ParserConfig) (Parser, error) {
+	if c == nil {
+		return &jsonParser{}, nil
+	}
+	switch strings.ToLower(c.Type) {
+	case "", TypeJSON:
+		return &jsonParser{}, nil
+	case TypeLenientJSON:
+		return makeLenientJSONParser(c.Prefix)
+	case TypeLogfmt:
+		return &logfmtParser{}, nil
+	case TypeRegex:
+		return makeRegexParser(c.Pattern)
+	case TypeCombined, "apache", "nginx":
+		return makeCombinedParser(), nil
+	case TypeCSV:
+		return makeCSVParser(c.Columns, c.Separator)
+	case TypeGrok:
+		return makeGrokParser(c.Pattern, c.Patterns)
+	}
+	return nil, fmt.Errorf("unknown parser %s, expected one of %s", c.Type,
+		strings.Join(Types(), ", "))
+}
+
+// Parse parses text with p. Only the first line of a multi-line text is parsed, the
+// lines after it being kept whole as config.Continuation. Trailing line breaks are
+// ignored.
+func Parse(p Parser, text []byte) (map[string]interface{}, error) {
+	first, rest, _ := bytes.Cut(bytes.TrimRight(text, "\r\n"), []byte("\n"))
+	m, err := p.Parse(bytes.TrimSuffix(first, []byte("\r")))
+	if err != nil || m == nil || len(rest) == 0 {
+		return m, err
+	}
+	m[config.Continuation] = string(rest)
+	return m, nil
+}
+
+// ParseSpec reads a parser given on the command line, as its type optionally
+// followed by a colon and its pattern, e.g. logfmt, regex:(?P<level>\w+) (?P<msg>.*)
+// or grok:%{LOGLEVEL:level} %{GREEDYDATA:msg}. The pattern of lenient-json is the
+// prefix one.
+func ParseSpec(spec string) (*config.ParserConfig, error) {
+	typ, pattern, _ := strings.Cut(spec, ":")
+	c := &config.ParserConfig{
+		Type: strings.ToLower(typ),
+	}
+	switch {
+	case len(pattern) == 0:
+	case c.Type == TypeRegex, c.Type == TypeGrok:
+		c.Pattern = pattern
+	case c.Type == TypeLenientJSON:
+		c.Prefix = pattern
+	default:
+		return nil, fmt.Errorf("parser %s takes no pattern", typ)
+	}
+	if _, err := MakeParser(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
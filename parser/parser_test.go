@@ -0,0 +1,173 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jimbertools/loggo/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeParser(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     *config.ParserConfig
+		wantsType Parser
+		wantsErr  bool
+	}{
+		{name: "Default", given: nil, wantsType: &jsonParser{}},
+		{name: "Empty type", given: &config.ParserConfig{}, wantsType: &jsonParser{}},
+		{name: "Logfmt", given: &config.ParserConfig{Type: "logfmt"}, wantsType: &logfmtParser{}},
+		{name: "Nginx", given: &config.ParserConfig{Type: "nginx"}, wantsType: &combinedParser{}},
+		{name: "Regex", given: &config.ParserConfig{Type: "regex", Pattern: `(?P<a>\w+)`}, wantsType: &regexParser{}},
+		{name: "Regex without pattern", given: &config.ParserConfig{Type: "regex"}, wantsErr: true},
+		{name: "Regex without names", given: &config.ParserConfig{Type: "regex", Pattern: `(\w+)`}, wantsErr: true},
+		{name: "Bad regex", given: &config.ParserConfig{Type: "regex", Pattern: `(?P<a>`}, wantsErr: true},
+		{name: "CSV", given: &config.ParserConfig{Type: "csv", Separator: ";"}, wantsType: &csvParser{}},
+		{name: "CSV bad separator", given: &config.ParserConfig{Type: "csv", Separator: ";;"}, wantsErr: true},
+		{name: "Grok", given: &config.ParserConfig{Type: "grok", Pattern: `%{INT:n}`}, wantsType: &grokParser{}},
+		{name: "Lenient JSON", given: &config.ParserConfig{Type: "lenient-json"}, wantsType: &lenientJSONParser{}},
+		{name: "Bad prefix", given: &config.ParserConfig{Type: "lenient-json", Prefix: "("}, wantsErr: true},
+		{name: "Unknown", given: &config.ParserConfig{Type: "xml"}, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := MakeParser(test.given)
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, test.wantsType, p)
+		})
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    string
+		wants    *config.ParserConfig
+		wantsErr bool
+	}{
+		{name: "Type", given: "logfmt", wants: &config.ParserConfig{Type: "logfmt"}},
+		{name: "Upper case", given: "CSV", wants: &config.ParserConfig{Type: "csv"}},
+		{
+			name:  "Regex",
+			given: `regex:(?P<level>\w+): (?P<msg>.*)`,
+			wants: &config.ParserConfig{Type: "regex", Pattern: `(?P<level>\w+): (?P<msg>.*)`},
+		},
+		{
+			name:  "Grok",
+			given: `grok:%{LOGLEVEL:level} %{GREEDYDATA:msg}`,
+			wants: &config.ParserConfig{Type: "grok", Pattern: `%{LOGLEVEL:level} %{GREEDYDATA:msg}`},
+		},
+		{
+			name:  "Lenient JSON",
+			given: `lenient-json:^(?P<level>\w+)`,
+			wants: &config.ParserConfig{Type: "lenient-json", Prefix: `^(?P<level>\w+)`},
+		},
+		{name: "Pattern on logfmt", given: "logfmt:foo", wantsErr: true},
+		{name: "Unknown", given: "foo", wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ParseSpec(test.given)
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, c)
+		})
+	}
+}
+
+func TestJsonParser_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    string
+		wants    map[string]interface{}
+		wantsErr bool
+	}{
+		{name: "Object", given: `{"a":"b","c":1}`, wants: map[string]interface{}{"a": "b", "c": json.Number("1")}},
+		{
+			name:  "Exact numbers",
+			given: `{"id":1234567890123456789,"ts":1759320000123456789,"big":123456789012345678901234567890,"f":0.1}`,
+			wants: map[string]interface{}{
+				"id":  json.Number("1234567890123456789"),
+				"ts":  json.Number("1759320000123456789"),
+				"big": json.Number("123456789012345678901234567890"),
+				"f":   json.Number("0.1"),
+			},
+		},
+		{
+			name:  "Nested numbers",
+			given: `{"a":{"b":[1,2.5e3]}}`,
+			wants: map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{json.Number("1"), json.Number("2.5e3")}}},
+		},
+		{name: "Surrounding spaces", given: ` {"a":"b"} `, wants: map[string]interface{}{"a": "b"}},
+		{name: "Null", given: `null`},
+		{name: "Plain text", given: `plain text`, wantsErr: true},
+		{name: "Empty", given: ``, wantsErr: true},
+		{name: "Trailing data", given: `{"a":"b"} trailing`, wantsErr: true},
+		{name: "Second object", given: `{"a":"b"}{"c":"d"}`, wantsErr: true},
+	}
+	p := &jsonParser{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := p.Parse([]byte(test.given))
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, m)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	p := &logfmtParser{}
+	m, err := Parse(p, []byte("level=error msg=failed\n\tat com.acme.App.run(App.java:42)\n\tat java.lang.Thread.run"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"level":             "error",
+		"msg":               "failed",
+		config.Continuation: "\tat com.acme.App.run(App.java:42)\n\tat java.lang.Thread.run",
+	}, m)
+
+	m, err = Parse(p, []byte("level=info"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"level": "info"}, m)
+
+	// Records read off a pipe end with their line break.
+	m, err = Parse(&jsonParser{}, []byte("{\"a\":1}\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": json.Number("1")}, m)
+
+	_, err = Parse(p, []byte("not logfmt\nlevel=info"))
+	assert.Error(t, err)
+}
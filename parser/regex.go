@@ -0,0 +1,70 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// regexParser takes the fields of a line from the named capture groups of its
+// pattern. Unnamed groups are left out.
+type regexParser struct {
+	re *regexp.Regexp
+}
+
+var errNoMatch = errors.New("line does not match the pattern")
+
+func makeRegexParser(pattern string) (*regexParser, error) {
+	if len(pattern) == 0 {
+		return nil, errors.New("regex parser requires a pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parser pattern: %w", err)
+	}
+	named := false
+	for _, n := range re.SubexpNames() {
+		named = named || len(n) > 0
+	}
+	if !named {
+		return nil, fmt.Errorf("parser pattern %s has no named groups, e.g. (?P<level>\\w+)", pattern)
+	}
+	return &regexParser{re: re}, nil
+}
+
+func (p *regexParser) Parse(line []byte) (map[string]interface{}, error) {
+	match := p.re.FindSubmatchIndex(line)
+	if match == nil {
+		return nil, errNoMatch
+	}
+	m := make(map[string]interface{})
+	for i, name := range p.re.SubexpNames() {
+		if len(name) == 0 || match[2*i] < 0 {
+			continue
+		}
+		m[name] = string(line[match[2*i]:match[2*i+1]])
+	}
+	return m, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexParser_Parse(t *testing.T) {
+	p, err := makeRegexParser(`^(?P<level>[A-Z]+) \[(?P<thread>[^\]]+)\] (?:(?P<logger>\S+): )?(?P<msg>.*)$`)
+	assert.NoError(t, err)
+
+	m, err := p.Parse([]byte(`WARN [main] com.acme.App: low disk`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"level": "WARN", "thread": "main", "logger": "com.acme.App", "msg": "low disk",
+	}, m)
+
+	m, err = p.Parse([]byte(`INFO [worker-1] started`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"level": "INFO", "thread": "worker-1", "msg": "started"}, m)
+
+	_, err = p.Parse([]byte(`started`))
+	assert.Error(t, err)
+}
+
+func TestCombinedParser_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    string
+		wants    map[string]interface{}
+		wantsErr bool
+	}{
+		{
+			name: "Combined",
+			given: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?a=1 HTTP/1.0" 200 2326 ` +
+				`"http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"`,
+			wants: map[string]interface{}{
+				"remoteAddr": "127.0.0.1",
+				"remoteUser": "frank",
+				"timestamp":  "2000-10-10T13:55:36-07:00",
+				"request":    "GET /apache_pb.gif?a=1 HTTP/1.0",
+				"method":     "GET",
+				"path":       "/apache_pb.gif?a=1",
+				"protocol":   "HTTP/1.0",
+				"status":     float64(200),
+				"bytes":      float64(2326),
+				"referer":    "http://www.example.com/start.html",
+				"userAgent":  "Mozilla/4.08 [en] (Win98; I ;Nav)",
+			},
+		},
+		{
+			name:  "Common",
+			given: `10.0.0.1 - - [01/Oct/2026:12:00:00 +0000] "POST /api HTTP/1.1" 204 -`,
+			wants: map[string]interface{}{
+				"remoteAddr": "10.0.0.1",
+				"timestamp":  "2026-10-01T12:00:00Z",
+				"request":    "POST /api HTTP/1.1",
+				"method":     "POST",
+				"path":       "/api",
+				"protocol":   "HTTP/1.1",
+				"status":     float64(204),
+			},
+		},
+		{
+			name:  "Nginx with empty referer",
+			given: `::1 - - [01/Oct/2026:12:00:00 +0000] "GET / HTTP/2.0" 404 153 "-" "curl/8.0"`,
+			wants: map[string]interface{}{
+				"remoteAddr": "::1",
+				"timestamp":  "2026-10-01T12:00:00Z",
+				"request":    "GET / HTTP/2.0",
+				"method":     "GET",
+				"path":       "/",
+				"protocol":   "HTTP/2.0",
+				"status":     float64(404),
+				"bytes":      float64(153),
+				"userAgent":  "curl/8.0",
+			},
+		},
+		{name: "Not an access log", given: `{"a":1}`, wantsErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := makeCombinedParser().Parse([]byte(test.given))
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, m)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package pkg is the stable facade for embedding loggo in other applications.
+// Unlike the internal packages, its constructors report problems as errors
+// instead of panicking.
+package pkg
+
+import (
+	"context"
+
+	"github.com/jimbertools/loggo/color"
+	"github.com/jimbertools/loggo/config"
+	"github.com/jimbertools/loggo/filter"
+	"github.com/jimbertools/loggo/loggo"
+	"github.com/jimbertools/loggo/reader"
+)
+
+// Theme is the palette used to render loggo's widgets.
+type Theme = color.Theme
+
+// Option configures a LoggoApp.
+type Option func(*options)
+
+type options struct {
+	templateFile string
+	offset       int64
+	filter       string
+	theme        *Theme
+}
+
+// WithTemplate sets the rendering template file, overriding any template
+// provided positionally.
+func WithTemplate(templateFile string) Option {
+	return func(o *options) {
+		o.templateFile = templateFile
+	}
+}
+
+// WithOffset starts reading the log file from the given byte offset.
+func WithOffset(offset int64) Option {
+	return func(o *options) {
+		o.offset = offset
+	}
+}
+
+// WithFilter applies a local filter expression (same syntax as the filter bar)
+// as soon as the app starts.
+func WithFilter(expression string) Option {
+	return func(o *options) {
+		o.filter = expression
+	}
+}
+
+// WithTheme renders the app with the given palette.
+func WithTheme(theme Theme) Option {
+	return func(o *options) {
+		o.theme = &theme
+	}
+}
+
+// LoggoApp is an embeddable loggo log viewer.
+type LoggoApp struct {
+	app        *loggo.LoggoApp
+	reader     reader.Reader
+	ownsReader bool
+}
+
+// NewLoggoApp builds a log viewer streaming fileName, or the stdin if fileName is
+// empty, rendered according to templateFile. An empty templateFile lets loggo
+// infer the layout from the stream.
+func NewLoggoApp(fileName, templateFile string, opts ...Option) (*LoggoApp, error) {
+	o := makeOptions(append([]Option{WithTemplate(templateFile)}, opts...))
+	r := reader.MakeReader(fileName, reader.WithOffset(o.offset))
+	app, err := newLoggoApp(r, o)
+	if err != nil {
+		return nil, err
+	}
+	app.ownsReader = true
+	return app, nil
+}
+
+// NewLoggoAppWithReader builds a log viewer streaming from an existing reader.
+// The reader remains owned by the caller.
+func NewLoggoAppWithReader(r reader.Reader, opts ...Option) (*LoggoApp, error) {
+	return newLoggoApp(r, makeOptions(opts))
+}
+
+func makeOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func newLoggoApp(r reader.Reader, o *options) (*LoggoApp, error) {
+	cfg, err := config.MakeConfig(o.templateFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.filter) > 0 {
+		if _, err := filter.ParseFilterExpression(o.filter); err != nil {
+			return nil, err
+		}
+	}
+	theme := color.DefaultTheme
+	if o.theme != nil {
+		theme = *o.theme
+	}
+	app, err := loggo.NewLoggoAppWithTheme(r, cfg, theme)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.filter) > 0 {
+		if err := app.SetFilter(o.filter); err != nil {
+			return nil, err
+		}
+	}
+	return &LoggoApp{
+		app:    app,
+		reader: r,
+	}, nil
+}
+
+// Run blocks until the UI quits or ctx is done.
+func (a *LoggoApp) Run(ctx context.Context) error {
+	err := a.app.RunContext(ctx)
+	if a.ownsReader {
+		a.reader.Close()
+	}
+	return err
+}
+
+// Stop quits the UI, causing Run to return.
+func (a *LoggoApp) Stop() {
+	a.app.Stop()
+}
+
+// NewReader builds a reader streaming fileName, or the stdin if fileName is
+// empty, into strChan once ChanReader is called. If strChan is nil, the reader
+// allocates its own channel. Readers handed over to NewLoggoAppWithReader are
+// consumed by the app instead, and ChanReader mustn't be called on them.
+func NewReader(fileName string, strChan chan string) reader.Reader {
+	var opts []reader.Option
+	if strChan != nil {
+		opts = append(opts, reader.WithStrChan(strChan))
+	}
+	return reader.MakeReader(fileName, opts...)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jimbertools/loggo/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLoggoApp(t *testing.T) {
+	tests := []struct {
+		name         string
+		givenFile    string
+		givenTmpl    string
+		givenOptions []Option
+		wantsError   bool
+	}{
+		{
+			name:      "Inferred layout",
+			givenFile: "../testdata/test1.json",
+		},
+		{
+			name:         "Filter and offset",
+			givenFile:    "../testdata/test1.json",
+			givenOptions: []Option{WithFilter(`level == "INFO"`), WithOffset(10)},
+		},
+		{
+			name:       "Non existing template",
+			givenFile:  "../testdata/test1.json",
+			givenTmpl:  "foo",
+			wantsError: true,
+		},
+		{
+			name:         "Non existing template option overrides positional",
+			givenFile:    "../testdata/test1.json",
+			givenOptions: []Option{WithTemplate("foo")},
+			wantsError:   true,
+		},
+		{
+			name:         "Bad filter expression",
+			givenFile:    "../testdata/test1.json",
+			givenOptions: []Option{WithFilter("severity = ")},
+			wantsError:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, err := NewLoggoApp(test.givenFile, test.givenTmpl, test.givenOptions...)
+			if test.wantsError {
+				assert.Error(t, err)
+				assert.Nil(t, app)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.NotNil(t, app) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				assert.ErrorIs(t, app.Run(ctx), context.Canceled)
+			}
+		})
+	}
+}
+
+func TestNewLoggoApp_InvalidMultiline(t *testing.T) {
+	tmpl := filepath.Join(t.TempDir(), "multiline.yaml")
+	assert.NoError(t, os.WriteFile(tmpl, []byte("multiline:\n  start: \"[\"\nkeys: []\n"), 0644))
+	app, err := NewLoggoApp("../testdata/test1.json", tmpl)
+	assert.Error(t, err)
+	assert.Nil(t, app)
+}
+
+func TestLoggoApp_RunCancelledContext(t *testing.T) {
+	app, err := NewLoggoAppWithReader(NewReader("", nil))
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, app.Run(ctx), context.Canceled)
+}
+
+func TestNewLoggoApp_ThemeIsScopedToTheApp(t *testing.T) {
+	theme := Theme{
+		Background:         tcell.ColorNavy,
+		Foreground:         tcell.ColorYellow,
+		SelectedBackground: tcell.ColorYellow,
+		SelectedForeground: tcell.ColorNavy,
+	}
+	themed, err := NewLoggoApp("../testdata/test1.json", "", WithTheme(theme))
+	assert.NoError(t, err)
+	plain, err := NewLoggoApp("../testdata/test1.json", "")
+	assert.NoError(t, err)
+	assert.Equal(t, theme, themed.app.Theme())
+	assert.Equal(t, color.DefaultTheme, plain.app.Theme())
+}
+
+func TestNewReader(t *testing.T) {
+	strChan := make(chan string, 1)
+	r := NewReader("", strChan)
+	assert.NotNil(t, r)
+	assert.Equal(t, (<-chan string)(strChan), r.ChanReader())
+	assert.NotNil(t, NewReader("", nil).ChanReader())
+}
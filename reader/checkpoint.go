@@ -0,0 +1,275 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	checkpointsPath = "checkpoints"
+	// maxCheckpointLine bounds how far back the checkpointed line is looked for.
+	maxCheckpointLine = 1 << 20
+)
+
+// Checkpoint marks how far a file has been read, so that a later session can resume
+// from there. The inode and the hash of the last line read tell whether the file is
+// still the same one, or whether it has been rotated in the meantime.
+type Checkpoint struct {
+	Path     string    `yaml:"path"`
+	Inode    uint64    `yaml:"inode"`
+	Offset   int64     `yaml:"offset"`
+	LineHash string    `yaml:"lineHash"`
+	Time     time.Time `yaml:"time"`
+}
+
+func checkpointFile(fileName string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(home, parentPath, checkpointsPath, hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+func hashLine(line string) string {
+	sum := sha256.Sum256([]byte(strings.TrimRight(line, "\r\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveCheckpoint stores cp under ~/.loggo, replacing any previous checkpoint of the
+// same file.
+func SaveCheckpoint(cp *Checkpoint) error {
+	fileName, err := checkpointFile(cp.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, b, 0644)
+}
+
+// LoadCheckpoint returns the last checkpoint saved for fileName, or nil if there's
+// none.
+func LoadCheckpoint(fileName string) (*Checkpoint, error) {
+	cpFile, err := checkpointFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(cpFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cp := Checkpoint{}
+	if err := yaml.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// resumePoint tells where reading a checkpointed file resumes.
+type resumePoint struct {
+	// rotated is the file the checkpointed content was rotated into, if any. The lines
+	// written there after the checkpoint are read before the file itself.
+	rotated       string
+	rotatedOffset int64
+	offset        int64
+}
+
+// resolve works out where to resume, following the file through logrotate's
+// create (moved aside, new file created) and copytruncate (copied aside, file
+// truncated in place) rotations.
+func (cp *Checkpoint) resolve() resumePoint {
+	if fi, err := os.Stat(cp.Path); err == nil && inode(fi) == cp.Inode && cp.matches(cp.Path) {
+		return resumePoint{offset: cp.Offset}
+	}
+	if rotated := cp.findRotated(); len(rotated) > 0 {
+		return resumePoint{rotated: rotated, rotatedOffset: cp.Offset}
+	}
+	return resumePoint{}
+}
+
+// matches checks that the line right before the checkpointed offset in fileName is
+// the one last read.
+func (cp *Checkpoint) matches(fileName string) bool {
+	if cp.Offset <= 0 {
+		return false
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	start := cp.Offset - maxCheckpointLine
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, cp.Offset-start)
+	if _, err := f.ReadAt(buf, start); err != nil {
+		return false
+	}
+	if !bytes.HasSuffix(buf, []byte("\n")) {
+		return false
+	}
+	buf = buf[:len(buf)-1]
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[i+1:]
+	} else if start > 0 {
+		return false
+	}
+	return hashLine(string(buf)) == cp.LineHash
+}
+
+// findRotated looks for the checkpointed content next to the file, e.g. app.log.1 or
+// app.log-20221001. Compressed files are skipped, as offsets can't be sought in them.
+func (cp *Checkpoint) findRotated() string {
+	var candidates []string
+	for _, pattern := range []string{cp.Path + ".*", cp.Path + "-*"} {
+		matches, _ := filepath.Glob(pattern)
+		candidates = append(candidates, matches...)
+	}
+	sort.Strings(candidates)
+
+	fallback := ""
+	for _, candidate := range candidates {
+		fi, err := os.Stat(candidate)
+		if err != nil || !fi.Mode().IsRegular() || detectCompression(candidate) != compressionNone {
+			continue
+		}
+		if !cp.matches(candidate) {
+			continue
+		}
+		// Moved aside files keep their inode, copied ones only their contents.
+		if inode(fi) == cp.Inode {
+			return candidate
+		}
+		if len(fallback) == 0 {
+			fallback = candidate
+		}
+	}
+	return fallback
+}
+
+// checkpointer tracks the last record read from each file, so that checkpoints can
+// be saved once the reader is done.
+type checkpointer struct {
+	mu     sync.Mutex
+	last   map[string]*Record
+	inodes map[string]uint64
+}
+
+func newCheckpointer() *checkpointer {
+	return &checkpointer{
+		last:   make(map[string]*Record),
+		inodes: make(map[string]uint64),
+	}
+}
+
+// watch starts tracking fileName.
+func (c *checkpointer) watch(fileName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inodes[fileName] = c.statInode(fileName)
+}
+
+func (c *checkpointer) statInode(fileName string) uint64 {
+	fi, err := os.Stat(fileName)
+	if err != nil {
+		return 0
+	}
+	return inode(fi)
+}
+
+// track records rec as the last one read from its source, if that's a tracked file.
+func (c *checkpointer) track(rec *Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.inodes[rec.Source]; !ok || rec.Offset <= 0 {
+		return
+	}
+	// Offsets only go back when the file got reopened after a rotation.
+	if last, ok := c.last[rec.Source]; ok && rec.Offset < last.Offset {
+		c.inodes[rec.Source] = c.statInode(rec.Source)
+	}
+	c.last[rec.Source] = rec
+}
+
+// save stores a checkpoint for every tracked file that was read from.
+func (c *checkpointer) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for fileName, rec := range c.last {
+		errs = append(errs, SaveCheckpoint(&Checkpoint{
+			Path:     fileName,
+			Inode:    c.inodes[fileName],
+			Offset:   rec.Offset,
+			LineHash: hashLine(rec.Text),
+			Time:     rec.Time,
+		}))
+	}
+	return errors.Join(errs...)
+}
+
+// streamRotated reads the lines written after the checkpoint into the file it was
+// rotated into.
+func (s *reader) streamRotated(p resumePoint) error {
+	if len(p.rotated) == 0 {
+		return nil
+	}
+	f, err := os.Open(p.rotated)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(p.rotatedOffset, io.SeekStart); err != nil {
+		return err
+	}
+	return readLines(f, p.rotatedOffset, s.done, func(line string, offset int64) bool {
+		return s.emit(&Record{Text: line, Source: p.rotated, Offset: offset})
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func appendFile(t *testing.T, fileName, content string) {
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(content)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+}
+
+func readToEnd(t *testing.T, fileName string) []string {
+	r := MakeReader(fileName, WithResume(), WithFollow(false))
+	assert.NoError(t, r.StreamInto())
+	var lines []string
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case rec, ok := <-r.Records():
+			if !ok {
+				r.Close()
+				return lines
+			}
+			lines = append(lines, rec.Text)
+		case <-timeout:
+			t.Fatalf("timeout reading %s, got %v", fileName, lines)
+		}
+	}
+}
+
+func TestCheckpoint_Resume(t *testing.T) {
+	tests := []struct {
+		name   string
+		rotate func(t *testing.T, fileName string)
+		wants  []string
+	}{
+		{
+			name: "Appended",
+			rotate: func(t *testing.T, fileName string) {
+				appendFile(t, fileName, "a4\n")
+			},
+			wants: []string{"a4"},
+		},
+		{
+			name: "Logrotate create",
+			rotate: func(t *testing.T, fileName string) {
+				appendFile(t, fileName, "a4\n")
+				assert.NoError(t, os.Rename(fileName, fileName+".1"))
+				appendFile(t, fileName, "b1\n")
+			},
+			wants: []string{"a4", "b1"},
+		},
+		{
+			name: "Logrotate copytruncate",
+			rotate: func(t *testing.T, fileName string) {
+				appendFile(t, fileName, "a4\n")
+				b, err := os.ReadFile(fileName)
+				assert.NoError(t, err)
+				assert.NoError(t, os.WriteFile(fileName+".1", b, 0644))
+				assert.NoError(t, os.Truncate(fileName, 0))
+				appendFile(t, fileName, "b1 is longer than before\n")
+			},
+			wants: []string{"a4", "b1 is longer than before"},
+		},
+		{
+			name: "Replaced without a rotated copy",
+			rotate: func(t *testing.T, fileName string) {
+				assert.NoError(t, os.Remove(fileName))
+				appendFile(t, fileName, "b1\n")
+			},
+			wants: []string{"b1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			fileName := filepath.Join(t.TempDir(), "app.log")
+			appendFile(t, fileName, "a1\na2\na3\n")
+
+			assert.Equal(t, []string{"a1", "a2", "a3"}, readToEnd(t, fileName))
+			cp, err := LoadCheckpoint(fileName)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(9), cp.Offset)
+			assert.Equal(t, hashLine("a3"), cp.LineHash)
+
+			test.rotate(t, fileName)
+			assert.Equal(t, test.wants, readToEnd(t, fileName))
+		})
+	}
+}
+
+func TestLoadCheckpoint_Missing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cp, err := LoadCheckpoint("nothing.log")
+	assert.NoError(t, err)
+	assert.Nil(t, cp)
+}
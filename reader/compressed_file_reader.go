@@ -0,0 +1,83 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"io"
+	"sync"
+)
+
+// compressedFileStream reads a gzip, zstd or bzip2 compressed file to the end.
+// Compressed files are archives, so there's nothing to follow once EOF is hit and
+// the stream finishes there.
+type compressedFileStream struct {
+	*reader
+	fileName    string
+	compression compression
+	offset      int64
+	rc          io.ReadCloser
+	wg          sync.WaitGroup
+}
+
+func (s *compressedFileStream) StreamInto() error {
+	var err error
+	s.rc, err = openDecompressed(s.fileName, s.compression)
+	if err != nil {
+		s.closeRecords()
+		return err
+	}
+	// Offsets refer to the decompressed content.
+	if s.offset > 0 {
+		if _, err := io.CopyN(io.Discard, s.rc, s.offset); err != nil {
+			s.closeRecords()
+			return err
+		}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := readLines(s.rc, s.offset, s.done, func(line string, offset int64) bool {
+			return s.emit(&Record{Text: line, Source: s.fileName, Offset: offset})
+		})
+		if err != nil {
+			if s.onError != nil {
+				s.onError(err)
+			}
+			// The stream can't go on, so it's over for the consumer all the same.
+			s.closeRecords()
+			return
+		}
+		s.finish()
+	}()
+	return nil
+}
+
+func (s *compressedFileStream) Close() {
+	s.stopEmitting()
+	s.wg.Wait()
+	if s.rc != nil {
+		_ = s.rc.Close()
+	}
+	s.closeRecords()
+}
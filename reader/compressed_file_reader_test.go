@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeGzip(t *testing.T, fileName string, content string) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	_, err := gz.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, os.WriteFile(fileName, b.Bytes(), 0644))
+}
+
+func writeZstd(t *testing.T, fileName string, content string) {
+	var b bytes.Buffer
+	zw, err := zstd.NewWriter(&b)
+	assert.NoError(t, err)
+	_, err = zw.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, os.WriteFile(fileName, b.Bytes(), 0644))
+}
+
+func collectLines(t *testing.T, r Reader, count int) []string {
+	var lines []string
+	timeout := time.After(3 * time.Second)
+	for len(lines) < count {
+		select {
+		case line := <-r.ChanReader():
+			lines = append(lines, line)
+		case <-timeout:
+			t.Fatalf("timeout waiting for lines, got %v", lines)
+		}
+	}
+	return lines
+}
+
+func TestCompressedFileStream_StreamInto(t *testing.T) {
+	tmpDir := t.TempDir()
+	gzFile := filepath.Join(tmpDir, "app.log.gz")
+	writeGzip(t, gzFile, "gz line 1\ngz line 2\r\ngz line 3")
+	zstFile := filepath.Join(tmpDir, "app.log.zst")
+	writeZstd(t, zstFile, "zst line 1\nzst line 2\nzst line 3\n")
+
+	tests := []struct {
+		name      string
+		givenFile string
+		givenOpts []Option
+		wants     []string
+	}{
+		{
+			name:      "Gzip",
+			givenFile: gzFile,
+			wants:     []string{"gz line 1", "gz line 2", "gz line 3"},
+		},
+		{
+			name:      "Zstd",
+			givenFile: zstFile,
+			wants:     []string{"zst line 1", "zst line 2", "zst line 3"},
+		},
+		{
+			name:      "Bzip2",
+			givenFile: "../testdata/test4.log.bz2",
+			wants:     []string{"bz line 1", "bz line 2", "bz line 3"},
+		},
+		{
+			name:      "Offset applies to decompressed content",
+			givenFile: zstFile,
+			givenOpts: []Option{WithOffset(11)},
+			wants:     []string{"zst line 2", "zst line 3"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := MakeReader(test.givenFile, test.givenOpts...)
+			assert.IsType(t, &compressedFileStream{}, r)
+			assert.NoError(t, r.StreamInto())
+			assert.Equal(t, test.wants, collectLines(t, r, len(test.wants)))
+			r.Close()
+		})
+	}
+}
+
+func TestCompressedFileStream_Offsets(t *testing.T) {
+	gzFile := filepath.Join(t.TempDir(), "app.log.gz")
+	writeGzip(t, gzFile, "a\nbb\r\nccc")
+
+	r := MakeReader(gzFile, WithOffset(2))
+	assert.NoError(t, r.StreamInto())
+	var offsets []int64
+	for rec := range r.Records() {
+		offsets = append(offsets, rec.Offset)
+		if len(offsets) == 2 {
+			break
+		}
+	}
+	r.Close()
+	assert.Equal(t, []int64{6, 9}, offsets)
+}
+
+func TestCompressedFileStream_ClosesRecordsOnError(t *testing.T) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	_, err := gz.Write(bytes.Repeat([]byte("line\n"), 1000))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	gzFile := filepath.Join(t.TempDir(), "app.log.gz")
+	assert.NoError(t, os.WriteFile(gzFile, b.Bytes()[:b.Len()-8], 0644))
+
+	r := MakeReader(gzFile)
+	errs := make(chan error, 1)
+	r.ErrorNotifier(func(err error) {
+		errs <- err
+	})
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	drainRecords(t, r)
+	assert.Error(t, <-errs)
+}
+
+func drainRecords(t *testing.T, r Reader) {
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case _, ok := <-r.Records():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timeout waiting for the records channel to be closed")
+		}
+	}
+}
+
+func TestDetectCompression(t *testing.T) {
+	assert.Equal(t, compressionBzip2, detectCompression("../testdata/test4.log.bz2"))
+	assert.Equal(t, compressionNone, detectCompression("../testdata/test1.json"))
+	assert.Equal(t, compressionNone, detectCompression("../testdata/nonexistent"))
+}
+
+func TestIsBzip2(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want bool
+	}{
+		{name: "First block", head: []byte("BZh91AY&SY"), want: true},
+		{name: "Empty stream", head: []byte("BZh9\x17\x72\x45\x38\x50\x90"), want: true},
+		{name: "Text", head: []byte("BZh is how it starts"), want: false},
+		{name: "Bad block size", head: []byte("BZh01AY&SY"), want: false},
+		{name: "Short", head: []byte("BZh9"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isBzip2(tt.head))
+		})
+	}
+}
+
+func TestDetectCompression_TextStartingWithBzip2Magic(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "bzh.log")
+	assert.NoError(t, os.WriteFile(fileName, []byte("BZh9 is not compressed\n"), 0644))
+	assert.Equal(t, compressionNone, detectCompression(fileName))
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type compression int
+
+const (
+	compressionNone = compression(iota)
+	compressionGzip
+	compressionZstd
+	compressionBzip2
+)
+
+var compressionMagic = map[compression][]byte{
+	compressionGzip:  {0x1f, 0x8b},
+	compressionZstd:  {0x28, 0xb5, 0x2f, 0xfd},
+	compressionBzip2: []byte("BZh"),
+}
+
+// bzip2BlockMagic holds the magic numbers that may follow the bzip2 stream header: the
+// one opening the first block, and the end-of-stream one of an empty stream.
+var bzip2BlockMagic = [][]byte{
+	{0x31, 0x41, 0x59, 0x26, 0x53, 0x59},
+	{0x17, 0x72, 0x45, 0x38, 0x50, 0x90},
+}
+
+// isBzip2 tells apart a bzip2 header (BZh, the block size '1'-'9' and a block magic)
+// from plain text that happens to start with "BZh".
+func isBzip2(head []byte) bool {
+	if len(head) < 10 || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+	for _, magic := range bzip2BlockMagic {
+		if bytes.Equal(head[4:10], magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectCompression sniffs the file's magic bytes. Files that can't be read are
+// reported as uncompressed, leaving the error for the tailer to surface.
+func detectCompression(fileName string) compression {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return compressionNone
+	}
+	defer f.Close()
+	head := make([]byte, 10)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	for c, magic := range compressionMagic {
+		if bytes.HasPrefix(head, magic) {
+			if c == compressionBzip2 && !isBzip2(head) {
+				continue
+			}
+			return c
+		}
+	}
+	return compressionNone
+}
+
+type decompressReader struct {
+	io.Reader
+	closers []func() error
+}
+
+func (d *decompressReader) Close() error {
+	var err error
+	for _, c := range d.closers {
+		if cErr := c(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+// openDecompressed opens fileName and decompresses it on the fly.
+func openDecompressed(fileName string, c compression) (io.ReadCloser, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	switch c {
+	case compressionGzip:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: gz, closers: []func() error{gz.Close, f.Close}}, nil
+	case compressionZstd:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []func() error{
+			func() error { zr.Close(); return nil }, f.Close}}, nil
+	case compressionBzip2:
+		return &decompressReader{Reader: bzip2.NewReader(f), closers: []func() error{f.Close}}, nil
+	}
+	return f, nil
+}
+
+// readLines feeds every line of r into emit until EOF or until stop is closed. Along
+// with the line goes the offset right past it, counted from offset.
+func readLines(r io.Reader, offset int64, stop <-chan struct{}, emit func(line string, offset int64) bool) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			offset += int64(len(line))
+			if !emit(strings.TrimRight(line, "\r\n"), offset) {
+				return nil
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+}
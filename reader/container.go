@@ -0,0 +1,135 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// MetaTime is the pseudo-field holding the time a container runtime logged a line at.
+	MetaTime = "$_time"
+	// MetaStream is the pseudo-field holding the container stream a line was written to.
+	MetaStream = "$_stream"
+
+	// maxPartialAge is how long a partial container line waits for its remainder.
+	maxPartialAge = 5 * time.Second
+)
+
+// criLine matches Kubernetes CRI log lines, e.g.
+// 2016-10-06T00:17:09.669794202Z stdout F log content
+var criLine = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+) (stdout|stderr) ([PF])(?::\S*)? ?(.*)$`)
+
+type dockerLine struct {
+	Log    *string `json:"log"`
+	Stream string  `json:"stream"`
+	Time   string  `json:"time"`
+}
+
+type partialLine struct {
+	rec   *Record
+	text  strings.Builder
+	since time.Time
+}
+
+// containerFramer unwraps Docker json-file and Kubernetes CRI log lines, re-joining
+// the lines the runtime split into partial ones. Other lines go through untouched.
+type containerFramer struct {
+	partials map[string]*partialLine
+}
+
+// NewContainerFramer builds a Framer unwrapping Docker json-file and Kubernetes CRI
+// container logs. The payload becomes the record's text, while the container time
+// and stream are kept as MetaTime and MetaStream.
+func NewContainerFramer() Framer {
+	return &containerFramer{
+		partials: make(map[string]*partialLine),
+	}
+}
+
+// unwrapContainerLine returns the payload of a Docker or CRI line, along with its
+// stream and time and whether it's only part of a line. The line break ending line, if
+// any, is ignored.
+func unwrapContainerLine(line string) (payload, stream, ts string, partial, ok bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, `{"log":`) {
+		dl := dockerLine{}
+		if err := json.Unmarshal([]byte(line), &dl); err == nil && dl.Log != nil && len(dl.Stream) > 0 {
+			payload = strings.TrimSuffix(*dl.Log, "\n")
+			// Docker splits long lines, only the last part ends with a new line.
+			partial = payload == *dl.Log
+			return strings.TrimSuffix(payload, "\r"), dl.Stream, dl.Time, partial, true
+		}
+		return "", "", "", false, false
+	}
+	if len(line) == 0 || line[0] < '0' || line[0] > '9' {
+		return "", "", "", false, false
+	}
+	m := criLine.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", "", false, false
+	}
+	return m[4], m[2], m[1], m[3] == "P", true
+}
+
+func (f *containerFramer) Frame(rec *Record) []*Record {
+	payload, stream, ts, partial, ok := unwrapContainerLine(rec.Text)
+	if !ok {
+		return []*Record{rec}
+	}
+	p, pending := f.partials[stream]
+	if !pending {
+		p = &partialLine{
+			rec: &Record{
+				Source: rec.Source,
+				Time:   rec.Time,
+				Meta:   map[string]string{MetaTime: ts, MetaStream: stream},
+			},
+			since: time.Now(),
+		}
+	}
+	p.text.WriteString(payload)
+	p.rec.Offset = rec.Offset
+	if partial {
+		f.partials[stream] = p
+		return nil
+	}
+	delete(f.partials, stream)
+	p.rec.Text = p.text.String()
+	return []*Record{p.rec}
+}
+
+func (f *containerFramer) Flush(force bool) []*Record {
+	var recs []*Record
+	for stream, p := range f.partials {
+		if force || time.Since(p.since) > maxPartialAge {
+			delete(f.partials, stream)
+			p.rec.Text = p.text.String()
+			recs = append(recs, p.rec)
+		}
+	}
+	return recs
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerFramer_Frame(t *testing.T) {
+	tests := []struct {
+		name       string
+		givenLines []string
+		wantsText  []string
+		wantsMeta  []map[string]string
+	}{
+		{
+			name: "Docker json-file",
+			givenLines: []string{
+				`{"log":"{\"level\":\"info\"}\n","stream":"stdout","time":"2022-10-01T12:00:00.1Z"}`,
+			},
+			wantsText: []string{`{"level":"info"}`},
+			wantsMeta: []map[string]string{{MetaTime: "2022-10-01T12:00:00.1Z", MetaStream: "stdout"}},
+		},
+		{
+			name: "Docker json-file split line",
+			givenLines: []string{
+				`{"log":"{\"msg\":\"very","stream":"stderr","time":"2022-10-01T12:00:00.1Z"}`,
+				`{"log":" long\"}\n","stream":"stderr","time":"2022-10-01T12:00:00.2Z"}`,
+			},
+			wantsText: []string{`{"msg":"very long"}`},
+			wantsMeta: []map[string]string{{MetaTime: "2022-10-01T12:00:00.1Z", MetaStream: "stderr"}},
+		},
+		{
+			name: "CRI partial lines of interleaved streams",
+			givenLines: []string{
+				`2022-10-01T12:00:00.1Z stdout P {"msg":`,
+				`2022-10-01T12:00:00.2Z stderr F oops`,
+				`2022-10-01T12:00:00.3Z stdout F "joined"}`,
+			},
+			wantsText: []string{`oops`, `{"msg":"joined"}`},
+			wantsMeta: []map[string]string{
+				{MetaTime: "2022-10-01T12:00:00.2Z", MetaStream: "stderr"},
+				{MetaTime: "2022-10-01T12:00:00.1Z", MetaStream: "stdout"},
+			},
+		},
+		{
+			name: "CRI lines ending with line breaks",
+			givenLines: []string{
+				"2022-10-01T12:00:00.1Z stdout P {\"msg\":\n",
+				"2022-10-01T12:00:00.2Z stdout F \"piped\"}\r\n",
+			},
+			wantsText: []string{`{"msg":"piped"}`},
+			wantsMeta: []map[string]string{{MetaTime: "2022-10-01T12:00:00.1Z", MetaStream: "stdout"}},
+		},
+		{
+			name:       "Plain lines go through",
+			givenLines: []string{`{"log":"not docker"}`, `2022-10-01 plain`},
+			wantsText:  []string{`{"log":"not docker"}`, `2022-10-01 plain`},
+			wantsMeta:  []map[string]string{nil, nil},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := NewContainerFramer()
+			var recs []*Record
+			for i, line := range test.givenLines {
+				recs = append(recs, f.Frame(&Record{Text: line, Source: "pod.log", Offset: int64(i + 1)})...)
+			}
+			assert.Empty(t, f.Flush(true))
+			var text []string
+			var meta []map[string]string
+			for _, rec := range recs {
+				text = append(text, rec.Text)
+				meta = append(meta, rec.Meta)
+				assert.Equal(t, "pod.log", rec.Source)
+			}
+			assert.Equal(t, test.wantsText, text)
+			assert.Equal(t, test.wantsMeta, meta)
+		})
+	}
+}
+
+func TestContainerFramer_Flush(t *testing.T) {
+	f := NewContainerFramer()
+	assert.Empty(t, f.Frame(&Record{Text: `2022-10-01T12:00:00.1Z stdout P cut sho`}))
+	assert.Empty(t, f.Flush(false))
+	recs := f.Flush(true)
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, "cut sho", recs[0].Text)
+	}
+}
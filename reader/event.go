@@ -0,0 +1,70 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventType tells what happened to the stream.
+type EventType int
+
+const (
+	// EventFileAdded is sent when a file starts being streamed after the reader started.
+	EventFileAdded = EventType(iota)
+	// EventFileRemoved is sent when a file being streamed disappears.
+	EventFileRemoved
+	// EventEndOfStream is sent once a reader that doesn't follow its input has
+	// streamed all of it.
+	EventEndOfStream
+	// EventRestarted is sent when a command exits and is about to be restarted.
+	EventRestarted
+)
+
+// Event reports a change in the stream itself, rather than in its contents.
+type Event struct {
+	Type EventType
+	// Source is the file the event is about, if any.
+	Source string
+	// Lines is the number of lines streamed so far.
+	Lines uint64
+	// Err is why the command exited, if it failed, for EventRestarted.
+	Err error
+	// Backoff is how long until the command is restarted, for EventRestarted.
+	Backoff time.Duration
+}
+
+func (e Event) String() string {
+	switch e.Type {
+	case EventFileAdded:
+		return fmt.Sprintf("started streaming %s", e.Source)
+	case EventFileRemoved:
+		return fmt.Sprintf("stopped streaming %s", e.Source)
+	case EventEndOfStream:
+		return fmt.Sprintf("stream complete (%d lines)", e.Lines)
+	case EventRestarted:
+		return fmt.Sprintf("command %q exited (%s), restarting in %v", e.Source, exitStatus(e.Err), e.Backoff)
+	}
+	return fmt.Sprintf("unknown event for %s", e.Source)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// Sources of the records streamed by the exec reader.
+	SourceStdout = "stdout"
+	SourceStderr = "stderr"
+
+	minExecBackoff = time.Second
+	maxExecBackoff = 30 * time.Second
+	// execStableAfter is how long the command must run for the backoff to reset.
+	execStableAfter = time.Minute
+)
+
+type execStream struct {
+	*reader
+	command    string
+	follow     bool
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// MakeExecReader builds a reader that runs command through the shell, streaming its
+// stdout and stderr as records sourced SourceStdout and SourceStderr respectively.
+// Whenever the command exits it's restarted, backing off exponentially if it keeps
+// failing, and the restart is reported through EventNotifier. Errors are only reported
+// once the command can't be restarted anymore. With WithFollow(false), the command
+// runs only once and the stream finishes when it exits.
+func MakeExecReader(command string, opts ...Option) Reader {
+	c := makeReaderConfig(opts)
+	return &execStream{
+		reader:     newReader(TypeExec, c.strChan),
+		command:    command,
+		follow:     c.follow,
+		minBackoff: minExecBackoff,
+		maxBackoff: maxExecBackoff,
+	}
+}
+
+func (s *execStream) StreamInto() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, err := s.start(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		backoff := s.minBackoff
+		for {
+			started := time.Now()
+			err := s.wait(cmd)
+			if !s.follow {
+				if err != nil {
+					s.report(fmt.Errorf("command %q exited: %w", s.command, err))
+				}
+				s.finish()
+				return
+			}
+			if time.Since(started) > execStableAfter {
+				backoff = s.minBackoff
+			}
+			select {
+			case <-s.done:
+				return
+			default:
+				s.notify(Event{Type: EventRestarted, Source: s.command, Err: err, Backoff: backoff})
+			}
+			select {
+			case <-s.done:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > s.maxBackoff {
+				backoff = s.maxBackoff
+			}
+			if cmd, err = s.start(ctx); err != nil {
+				s.report(fmt.Errorf("failed to restart command %q: %w", s.command, err))
+				s.finish()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+type runningCmd struct {
+	*exec.Cmd
+	output sync.WaitGroup
+}
+
+// start launches the command, streaming its output until it exits.
+func (s *execStream) start(ctx context.Context) (*runningCmd, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := &runningCmd{Cmd: exec.CommandContext(ctx, shell, flag, s.command)}
+	// Children of the shell may hold on to its output once it's killed.
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	cmd.output.Add(2)
+	s.wg.Add(2)
+	go s.stream(&cmd.output, stdout, SourceStdout)
+	go s.stream(&cmd.output, stderr, SourceStderr)
+	return cmd, nil
+}
+
+func (s *execStream) stream(output *sync.WaitGroup, r io.Reader, source string) {
+	defer s.wg.Done()
+	defer output.Done()
+	_ = readLines(r, 0, s.done, func(line string, offset int64) bool {
+		return s.emit(&Record{Text: line, Source: source})
+	})
+	// Keep draining, so that the command doesn't block on a full pipe.
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// wait waits for the command to exit, once all of its output has been read or the
+// reader has been closed.
+func (s *execStream) wait(cmd *runningCmd) error {
+	read := make(chan struct{})
+	go func() {
+		cmd.output.Wait()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-s.done:
+	}
+	return cmd.Wait()
+}
+
+// report passes err on to the error notifier, unless the reader has been closed.
+func (s *execStream) report(err error) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func exitStatus(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
+
+func (s *execStream) Close() {
+	s.stopEmitting()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.closeRecords()
+}
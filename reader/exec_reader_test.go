@@ -0,0 +1,137 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecReader(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	t.Run("Restarts with backoff", func(t *testing.T) {
+		events := make(chan Event, 10)
+		r := MakeExecReader("echo out; echo err >&2; exit 3")
+		r.(*execStream).minBackoff = 10 * time.Millisecond
+		r.ErrorNotifier(func(err error) {
+			t.Errorf("unexpected error %v", err)
+		})
+		r.EventNotifier(func(e Event) {
+			events <- e
+		})
+		assert.NoError(t, r.StreamInto())
+		defer r.Close()
+
+		// Twice the output, as the command gets restarted in between
+		got := make(map[string]int)
+		for i := 0; i < 4; i++ {
+			select {
+			case rec := <-r.Records():
+				got[rec.Source+":"+rec.Text]++
+			case <-time.After(3 * time.Second):
+				t.Fatalf("timeout waiting for output, got %v", got)
+			}
+		}
+		assert.Equal(t, map[string]int{"stdout:out": 2, "stderr:err": 2}, got)
+		e := <-events
+		assert.Equal(t, EventRestarted, e.Type)
+		assert.Equal(t, 10*time.Millisecond, e.Backoff)
+		assert.Contains(t, e.String(), "exit status 3")
+		assert.Contains(t, e.String(), "restarting in 10ms")
+		assert.Equal(t, 20*time.Millisecond, (<-events).Backoff)
+	})
+
+	t.Run("Runs once without follow", func(t *testing.T) {
+		events := make(chan Event, 1)
+		r := MakeExecReader("echo one; echo two", WithFollow(false))
+		r.EventNotifier(func(e Event) {
+			events <- e
+		})
+		assert.NoError(t, r.StreamInto())
+		var lines []string
+		for line := range r.ChanReader() {
+			lines = append(lines, line)
+		}
+		assert.Equal(t, []string{"one", "two"}, lines)
+		assert.Equal(t, Event{Type: EventEndOfStream, Lines: 2}, <-events)
+		r.Close()
+	})
+
+	t.Run("Finishes when it can't restart", func(t *testing.T) {
+		errs := make(chan error, 10)
+		events := make(chan Event, 10)
+		r := MakeExecReader("echo once; exit 1")
+		r.(*execStream).minBackoff = 10 * time.Millisecond
+		r.ErrorNotifier(func(err error) {
+			errs <- err
+		})
+		r.EventNotifier(func(e Event) {
+			events <- e
+		})
+		assert.NoError(t, r.StreamInto())
+		defer r.Close()
+		// The shell can't be found anymore by the time the command is restarted.
+		t.Setenv("PATH", "")
+
+		var lines []string
+		timeout := time.After(3 * time.Second)
+		for done := false; !done; {
+			select {
+			case rec, ok := <-r.Records():
+				if !ok {
+					done = true
+					break
+				}
+				lines = append(lines, rec.Text)
+			case <-timeout:
+				t.Fatalf("timeout waiting for the end of stream, got %v", lines)
+			}
+		}
+		assert.Equal(t, []string{"once"}, lines)
+		assert.Equal(t, EventRestarted, (<-events).Type)
+		assert.Equal(t, Event{Type: EventEndOfStream, Lines: 1}, <-events)
+		assert.Contains(t, (<-errs).Error(), "failed to restart")
+	})
+
+	t.Run("Close stops a running command", func(t *testing.T) {
+		r := MakeExecReader("echo started; sleep 30")
+		assert.NoError(t, r.StreamInto())
+		assert.Equal(t, "started", (<-r.Records()).Text)
+		closed := make(chan struct{})
+		go func() {
+			r.Close()
+			close(closed)
+		}()
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout closing the reader")
+		}
+	})
+}
@@ -24,21 +24,33 @@ package reader
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/nxadm/tail"
 )
 
 type fileStream struct {
-	reader
+	*reader
 	fileName string
 	tail     *tail.Tail
 	offset   int64
+	follow   bool
+	wg       sync.WaitGroup
+	mu       sync.Mutex
 }
 
 func (s *fileStream) StreamInto() error {
-	var err error
+	rp, resumed := s.resumePoint(s.fileName)
+	if resumed {
+		s.offset = rp.offset
+	}
+	if s.checkpoints != nil {
+		s.checkpoints.watch(s.fileName)
+	}
+
 	config := tail.Config{
-		Follow: true,
+		Follow: s.follow,
+		ReOpen: s.follow,
 		Poll:   true,
 	}
 
@@ -46,20 +58,44 @@ func (s *fileStream) StreamInto() error {
 		config.Location = &tail.SeekInfo{Whence: 0, Offset: s.offset}
 	}
 
-	s.tail, err = tail.TailFile(s.fileName, config)
+	t, err := tail.TailFile(s.fileName, config)
 	if err != nil {
 		return err
 	}
 
+	// Close may be racing with us, in which case the tail is stopped right away.
+	s.mu.Lock()
+	s.tail = t
+	s.wg.Add(1)
+	select {
+	case <-s.done:
+		t.Kill(fmt.Errorf("stopped by Close method"))
+	default:
+	}
+	s.mu.Unlock()
+
 	go func() {
-		for line := range s.tail.Lines {
-			s.strChan <- line.Text
+		defer s.wg.Done()
+		if err := s.streamRotated(rp); err != nil && s.onError != nil {
+			s.onError(err)
+		}
+		for line := range t.Lines {
+			s.emit(&Record{Text: line.Text, Source: s.fileName, Offset: line.SeekInfo.Offset, Time: line.Time})
+		}
+		if !s.follow {
+			s.finish()
 		}
 	}()
 	return nil
 }
 
 func (s *fileStream) Close() {
-	s.tail.Kill(fmt.Errorf("stopped by Close method"))
-	close(s.strChan)
+	s.stopEmitting()
+	s.mu.Lock()
+	if s.tail != nil {
+		s.tail.Kill(fmt.Errorf("stopped by Close method"))
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	s.closeRecords()
 }
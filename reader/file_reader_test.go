@@ -47,7 +47,7 @@ func TestFileStream_StreamInto(t *testing.T) {
 		// Routine to write file lines
 		before := time.Now().UnixMilli()
 		streamReceiver := make(chan string, 1)
-		reader := MakeReader(filePath, streamReceiver)
+		reader := MakeReader(filePath, WithStrChan(streamReceiver))
 		go func() {
 			for i := 0; i < 10; i++ {
 				file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
@@ -65,7 +65,7 @@ func TestFileStream_StreamInto(t *testing.T) {
 		var lines []string
 		_ = reader.StreamInto()
 		for {
-			line, ok := <-streamReceiver
+			line, ok := <-reader.ChanReader()
 			if !ok {
 				break
 			}
@@ -80,3 +80,89 @@ func TestFileStream_StreamInto(t *testing.T) {
 		assert.True(t, diff >= int64(1))
 	})
 }
+
+func TestFileStream_Records(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(filePath, []byte("first\nsecond line\n"), 0644))
+
+	tests := []struct {
+		name        string
+		givenOpts   []Option
+		wantsText   []string
+		wantsOffset []int64
+	}{
+		{
+			name:        "From the start",
+			wantsText:   []string{"first", "second line"},
+			wantsOffset: []int64{6, 18},
+		},
+		{
+			name:        "From an offset",
+			givenOpts:   []Option{WithOffset(6)},
+			wantsText:   []string{"second line"},
+			wantsOffset: []int64{18},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := time.Now()
+			reader := MakeReader(filePath, test.givenOpts...)
+			assert.NoError(t, reader.StreamInto())
+			for i := range test.wantsText {
+				select {
+				case rec := <-reader.Records():
+					assert.Equal(t, test.wantsText[i], rec.Text)
+					assert.Equal(t, filePath, rec.Source)
+					assert.Equal(t, test.wantsOffset[i], rec.Offset)
+					assert.Equal(t, uint64(i+1), rec.Seq)
+					assert.False(t, rec.Time.Before(before))
+				case <-time.After(2 * time.Second):
+					t.Fatalf("timeout waiting for %q", test.wantsText[i])
+				}
+			}
+			reader.Close()
+		})
+	}
+}
+
+func TestFileStream_NoFollow(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(filePath, []byte("line 1\nline 2\nline 3\n"), 0644))
+
+	events := make(chan Event, 1)
+	reader := MakeReader(filePath, WithFollow(false))
+	reader.EventNotifier(func(e Event) {
+		events <- e
+	})
+	assert.NoError(t, reader.StreamInto())
+
+	var lines []string
+	for line := range reader.ChanReader() {
+		lines = append(lines, line)
+	}
+	assert.Equal(t, []string{"line 1", "line 2", "line 3"}, lines)
+	select {
+	case e := <-events:
+		assert.Equal(t, Event{Type: EventEndOfStream, Lines: 3}, e)
+		assert.Equal(t, "stream complete (3 lines)", e.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for the end of stream")
+	}
+	reader.Close()
+}
+
+func TestFileStream_RecordsWithStrChan(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "app.log")
+	assert.NoError(t, os.WriteFile(filePath, []byte("line 1\nline 2\nline 3\n"), 0644))
+
+	// The string channel isn't fed until ChanReader is called, so that no record is
+	// diverted from Records.
+	reader := MakeReader(filePath, WithStrChan(make(chan string, 10)), WithFollow(false))
+	assert.NoError(t, reader.StreamInto())
+	var lines []string
+	for rec := range reader.Records() {
+		lines = append(lines, rec.Text)
+	}
+	assert.Equal(t, []string{"line 1", "line 2", "line 3"}, lines)
+	reader.Close()
+}
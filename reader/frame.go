@@ -0,0 +1,149 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"sync"
+	"time"
+)
+
+// flushInterval is how often framers are asked for records held back for too long.
+const flushInterval = 100 * time.Millisecond
+
+// Framer reframes the lines of a single source into records, e.g. by unwrapping or
+// joining them. A framer may hold lines back until it knows how they end.
+type Framer interface {
+	// Frame is fed each line of the source, returning the records ready to be emitted.
+	Frame(rec *Record) []*Record
+	// Flush returns the records held back for too long, or all of them if force is set
+	// as the stream is ending.
+	Flush(force bool) []*Record
+}
+
+type framedStream struct {
+	*reader
+	inner     Reader
+	newFramer func() Framer
+	framers   map[string]Framer
+	ended     chan struct{}
+	endOnce   sync.Once
+	wg        sync.WaitGroup
+}
+
+// Frame wraps inner so that its records go through framers built by newFramer, one
+// per source so that lines of different sources never get mixed.
+func Frame(inner Reader, newFramer func() Framer) Reader {
+	readerType := TypePipe
+	if typed, ok := inner.(interface{ Type() Type }); ok {
+		readerType = typed.Type()
+	}
+	s := &framedStream{
+		reader:    newReader(readerType, nil),
+		inner:     inner,
+		newFramer: newFramer,
+		framers:   make(map[string]Framer),
+		ended:     make(chan struct{}),
+	}
+	// The end of the inner stream is only reported once the framers are flushed.
+	inner.EventNotifier(func(e Event) {
+		if e.Type == EventEndOfStream {
+			s.endOnce.Do(func() { close(s.ended) })
+			return
+		}
+		s.notify(e)
+	})
+	inner.ErrorNotifier(func(err error) {
+		if s.onError != nil {
+			s.onError(err)
+		}
+	})
+	return s
+}
+
+func (s *framedStream) StreamInto() error {
+	if err := s.inner.StreamInto(); err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go s.frame()
+	return nil
+}
+
+func (s *framedStream) frame() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	records := s.inner.Records()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			for _, f := range s.framers {
+				if !s.emitAll(f.Flush(false)) {
+					return
+				}
+			}
+		case rec, ok := <-records:
+			if !ok {
+				for _, f := range s.framers {
+					if !s.emitAll(f.Flush(true)) {
+						return
+					}
+				}
+				select {
+				case <-s.ended:
+					s.finish()
+				default:
+					// The inner reader was closed on its own.
+					s.closeRecords()
+				}
+				return
+			}
+			f, ok := s.framers[rec.Source]
+			if !ok {
+				f = s.newFramer()
+				s.framers[rec.Source] = f
+			}
+			if !s.emitAll(f.Frame(rec)) {
+				return
+			}
+		}
+	}
+}
+
+func (s *framedStream) emitAll(recs []*Record) bool {
+	for _, rec := range recs {
+		if !s.emit(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *framedStream) Close() {
+	s.stopEmitting()
+	s.inner.Close()
+	s.wg.Wait()
+	s.closeRecords()
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrame(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "pod.log")
+	assert.NoError(t, os.WriteFile(fileName, []byte(
+		"2022-10-01T12:00:00.1Z stdout P first \n"+
+			"2022-10-01T12:00:00.2Z stdout F line\n"+
+			"2022-10-01T12:00:00.3Z stdout P dangling\n"), 0644))
+
+	events := make(chan Event, 1)
+	r := Frame(MakeReader(fileName, WithFollow(false)), NewContainerFramer)
+	r.EventNotifier(func(e Event) {
+		events <- e
+	})
+	assert.NoError(t, r.StreamInto())
+
+	var lines []string
+	timeout := time.After(3 * time.Second)
+	for done := false; !done; {
+		select {
+		case line, ok := <-r.ChanReader():
+			if !ok {
+				done = true
+				break
+			}
+			lines = append(lines, line)
+		case <-timeout:
+			t.Fatalf("timeout waiting for the stream to end, got %v", lines)
+		}
+	}
+	assert.Equal(t, []string{"first line", "dangling"}, lines)
+	assert.Equal(t, Event{Type: EventEndOfStream, Lines: 2}, <-events)
+	r.Close()
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const gcpTokenURL = "https://oauth2.googleapis.com/token"
+
+// TokenSource yields a valid OAuth2 access token for the GCP Logging API.
+type TokenSource func() (string, error)
+
+// GCloudTokenSource obtains access tokens from the gcloud CLI installed on the
+// system. If forceAuth is set, the user is asked to log in again before the
+// first token is issued.
+func GCloudTokenSource(forceAuth bool) TokenSource {
+	var once sync.Once
+	return func() (string, error) {
+		var err error
+		once.Do(func() {
+			if forceAuth {
+				cmd := exec.Command("gcloud", "auth", "login")
+				cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+				err = cmd.Run()
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+		out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		if err != nil {
+			return "", fmt.Errorf("gcloud auth print-access-token: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+type adcFile struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// NativeTokenSource exchanges the refresh token stored in the Application
+// Default Credentials file for access tokens, without requiring the gcloud CLI
+// at runtime. Tokens are cached until shortly before they expire.
+func NativeTokenSource() TokenSource {
+	var token string
+	var expiry time.Time
+	return func() (string, error) {
+		if len(token) > 0 && time.Now().Before(expiry) {
+			return token, nil
+		}
+		b, err := os.ReadFile(adcPath())
+		if err != nil {
+			return "", fmt.Errorf("no application default credentials found, "+
+				"run 'gcloud auth application-default login' or use --gcloud-auth: %w", err)
+		}
+		adc := adcFile{}
+		if err := json.Unmarshal(b, &adc); err != nil {
+			return "", err
+		}
+		if adc.Type != "authorized_user" {
+			return "", fmt.Errorf("credentials of type %q are not supported, use --gcloud-auth", adc.Type)
+		}
+		resp, err := http.PostForm(gcpTokenURL, url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {adc.ClientID},
+			"client_secret": {adc.ClientSecret},
+			"refresh_token": {adc.RefreshToken},
+		})
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		tr := struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int64  `json:"expires_in"`
+			Error       string `json:"error_description"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+			return "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unable to refresh GCP access token: %s", tr.Error)
+		}
+		token = tr.AccessToken
+		expiry = time.Now().Add(time.Duration(tr.ExpiresIn)*time.Second - time.Minute)
+		return token, nil
+	}
+}
+
+func adcPath() string {
+	if p := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); len(p) > 0 {
+		return p
+	}
+	if runtime.GOOS == "windows" {
+		return path.Join(os.Getenv("APPDATA"), "gcloud", "application_default_credentials.json")
+	}
+	home, _ := os.UserHomeDir()
+	return path.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
@@ -0,0 +1,244 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcpEntriesURL   = "https://logging.googleapis.com/v2/entries:list"
+	gcpPollInterval = 2 * time.Second
+	gcpPageSize     = 1000
+	// maxGCPBackoff bounds how long to wait before polling again after failures.
+	maxGCPBackoff = time.Minute
+)
+
+type gcpStream struct {
+	*reader
+	url         string
+	projectID   string
+	filter      string
+	from        time.Time
+	tokenSource TokenSource
+	token       string
+	client      *http.Client
+	lastTime    string
+	seen        map[string]bool
+	interval    time.Duration
+	maxBackoff  time.Duration
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+type gcpListRequest struct {
+	ResourceNames []string `json:"resourceNames"`
+	Filter        string   `json:"filter,omitempty"`
+	OrderBy       string   `json:"orderBy"`
+	PageSize      int      `json:"pageSize"`
+	PageToken     string   `json:"pageToken,omitempty"`
+}
+
+type gcpListResponse struct {
+	Entries       []json.RawMessage `json:"entries"`
+	NextPageToken string            `json:"nextPageToken"`
+}
+
+// MakeGCPReader builds a reader that polls GCP Cloud Logging for the given project,
+// streaming every entry matching filter from the provided point in time onwards.
+// Failed polls are reported through ErrorNotifier and retried, backing off
+// exponentially while they keep failing.
+func MakeGCPReader(projectID, filter string, from time.Time, tokenSource TokenSource, opts ...Option) Reader {
+	c := readerConfig{}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &gcpStream{
+		reader:      newReader(TypeGCP, c.strChan),
+		url:         gcpEntriesURL,
+		projectID:   projectID,
+		filter:      filter,
+		from:        from,
+		tokenSource: tokenSource,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		seen:        make(map[string]bool),
+		interval:    gcpPollInterval,
+		maxBackoff:  maxGCPBackoff,
+	}
+}
+
+func (s *gcpStream) StreamInto() error {
+	var err error
+	s.token, err = s.tokenSource()
+	if err != nil {
+		return fmt.Errorf("unable to authenticate with GCP: %w", err)
+	}
+	// Cancelled by Close, so that it needn't wait for a pending request.
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		wait := s.interval
+		for {
+			if err := s.poll(ctx); err != nil {
+				if wait *= 2; wait > s.maxBackoff {
+					wait = s.maxBackoff
+				}
+				s.report(fmt.Errorf("%w, retrying in %v", err, wait))
+			} else {
+				wait = s.interval
+			}
+			select {
+			case <-s.done:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *gcpStream) poll(ctx context.Context) error {
+	pageToken := ""
+	for {
+		resp, err := s.list(ctx, &gcpListRequest{
+			ResourceNames: []string{"projects/" + s.projectID},
+			Filter:        s.currentFilter(),
+			OrderBy:       "timestamp asc",
+			PageSize:      gcpPageSize,
+			PageToken:     pageToken,
+		})
+		if err != nil {
+			return err
+		}
+		for _, entry := range resp.Entries {
+			var meta struct {
+				InsertID  string `json:"insertId"`
+				Timestamp string `json:"timestamp"`
+			}
+			_ = json.Unmarshal(entry, &meta)
+			// Entries sharing the last seen timestamp are fetched again on the next
+			// poll, so they're de-duplicated by their insert id.
+			if meta.Timestamp != s.lastTime {
+				s.lastTime = meta.Timestamp
+				s.seen = make(map[string]bool)
+			}
+			if s.seen[meta.InsertID] {
+				continue
+			}
+			s.seen[meta.InsertID] = true
+			if !s.emit(&Record{Text: string(entry)}) {
+				return nil
+			}
+		}
+		if len(resp.NextPageToken) == 0 {
+			return nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+func (s *gcpStream) currentFilter() string {
+	from := s.lastTime
+	if len(from) == 0 {
+		from = s.from.UTC().Format(time.RFC3339Nano)
+	}
+	f := fmt.Sprintf(`timestamp>="%s"`, from)
+	if len(strings.TrimSpace(s.filter)) > 0 {
+		f = fmt.Sprintf(`(%s) AND %s`, s.filter, f)
+	}
+	return f
+}
+
+func (s *gcpStream) list(ctx context.Context, req *gcpListRequest) (*gcpListResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+s.token)
+		httpResp, err := s.client.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(httpResp.Body)
+		_ = httpResp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// Access tokens are short-lived, refresh once before giving up.
+		if httpResp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if s.token, err = s.tokenSource(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if httpResp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GCP logging request failed with status %d: %s",
+				httpResp.StatusCode, strings.TrimSpace(string(b)))
+		}
+		resp := &gcpListResponse{}
+		if err := json.Unmarshal(b, resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// report passes err on to the error notifier, unless the reader has been closed.
+func (s *gcpStream) report(err error) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *gcpStream) Close() {
+	s.stopEmitting()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	s.closeRecords()
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPReader_RetriesFailedPolls(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if calls.Add(1) <= 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"entries":[{"insertId":"a","timestamp":"2022-10-01T12:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	errs := make(chan error, 10)
+	r := MakeGCPReader("project", "", time.Now(), func() (string, error) {
+		return "token", nil
+	})
+	s := r.(*gcpStream)
+	s.url = server.URL
+	s.interval = 10 * time.Millisecond
+	r.ErrorNotifier(func(err error) {
+		errs <- err
+	})
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+
+	select {
+	case rec := <-r.Records():
+		assert.JSONEq(t, `{"insertId":"a","timestamp":"2022-10-01T12:00:00Z"}`, rec.Text)
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for the entry")
+	}
+	err := <-errs
+	assert.Contains(t, err.Error(), "status 503")
+	assert.Contains(t, err.Error(), "retrying in 20ms")
+	assert.Contains(t, (<-errs).Error(), "retrying in 40ms")
+}
+
+func TestGCPReader_CloseCancelsPendingPoll(t *testing.T) {
+	requested, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	r := MakeGCPReader("project", "", time.Now(), func() (string, error) {
+		return "token", nil
+	})
+	r.(*gcpStream).url = server.URL
+	assert.NoError(t, r.StreamInto())
+	<-requested
+
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close waited for the pending poll")
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxJournalField bounds the size of binary fields in the export format.
+const maxJournalField = 64 * 1024 * 1024
+
+type journaldStream struct {
+	*reader
+	fileName string
+	file     *os.File
+	mu       sync.Mutex
+}
+
+// MakeJournaldReader builds a reader for the output of journalctl, in either the
+// json (-o json) or the binary safe export (-o export) format, told apart by the
+// first entry. It reads from fileName or, if not provided, from the stdin, until
+// the input ends. Entries are streamed as JSON records with their fields, plus a
+// severity named after PRIORITY and a timestamp taken from __REALTIME_TIMESTAMP.
+func MakeJournaldReader(fileName string, strChan chan string) Reader {
+	return &journaldStream{
+		reader:   newReader(TypeJournald, strChan),
+		fileName: fileName,
+	}
+}
+
+func (s *journaldStream) StreamInto() error {
+	in := os.Stdin
+	if len(s.fileName) > 0 {
+		f, err := os.Open(s.fileName)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.file = f
+		s.mu.Unlock()
+		in = f
+	}
+
+	go func() {
+		// The stdin can't be interrupted, so the records channel is only closed
+		// once the pending read returns.
+		defer s.closeRecords()
+		if err := s.read(bufio.NewReader(in)); err != nil {
+			select {
+			case <-s.done:
+			default:
+				if s.onError != nil {
+					s.onError(err)
+				}
+			}
+			return
+		}
+		s.finish()
+	}()
+	return nil
+}
+
+func (s *journaldStream) read(br *bufio.Reader) error {
+	next := readJournalExportEntry
+	if isJournalJSON(br) {
+		next = readJournalJSONEntry
+	}
+	var offset int64
+	for {
+		entry, n, err := next(br)
+		offset += n
+		// Malformed entries are passed on as they are, to show as parse errors.
+		var malformed *malformedEntryError
+		if errors.As(err, &malformed) {
+			if !s.emit(&Record{Text: malformed.text, Source: s.fileName, Offset: offset}) {
+				return nil
+			}
+			continue
+		}
+		if entry != nil {
+			b, mErr := json.Marshal(normalizeJournalEntry(entry))
+			if mErr != nil {
+				return mErr
+			}
+			if !s.emit(&Record{Text: string(b), Source: s.fileName, Offset: offset}) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// isJournalJSON tells whether the input is in the json format, looking ahead for
+// the first entry.
+func isJournalJSON(br *bufio.Reader) bool {
+	for i := 1; ; i++ {
+		b, err := br.Peek(i)
+		if err != nil {
+			return false
+		}
+		switch b[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return true
+		}
+		return false
+	}
+}
+
+// malformedEntryError reports an entry that isn't valid, which doesn't stop reading
+// the ones after it.
+type malformedEntryError struct {
+	text string
+	err  error
+}
+
+func (e *malformedEntryError) Error() string {
+	return fmt.Sprintf("invalid journal entry: %v", e.err)
+}
+
+func (e *malformedEntryError) Unwrap() error {
+	return e.err
+}
+
+func readJournalJSONEntry(br *bufio.Reader) (map[string]interface{}, int64, error) {
+	for {
+		line, err := br.ReadBytes('\n')
+		n := int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			entry := make(map[string]interface{})
+			if uErr := json.Unmarshal(line, &entry); uErr != nil {
+				return nil, n, &malformedEntryError{text: string(line), err: uErr}
+			}
+			return entry, n, err
+		}
+		if err != nil {
+			return nil, n, err
+		}
+	}
+}
+
+// readJournalExportEntry reads an entry of the journal export format: fields as
+// KEY=value lines, or for binary fields a KEY line followed by the 64-bit little
+// endian size of the data, the data and a new line. Entries end with an empty line.
+func readJournalExportEntry(br *bufio.Reader) (map[string]interface{}, int64, error) {
+	var entry map[string]interface{}
+	var n int64
+	for {
+		line, err := br.ReadBytes('\n')
+		n += int64(len(line))
+		if err != nil {
+			if err == io.EOF && len(line) == 0 {
+				return entry, n, io.EOF
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, n, err
+		}
+		line = line[:len(line)-1]
+		if len(line) == 0 {
+			if entry == nil {
+				continue
+			}
+			return entry, n, nil
+		}
+		if entry == nil {
+			entry = make(map[string]interface{})
+		}
+		if i := bytes.IndexByte(line, '='); i >= 0 {
+			addJournalField(entry, string(line[:i]), string(line[i+1:]))
+			continue
+		}
+		var size [8]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return nil, n, err
+		}
+		l := binary.LittleEndian.Uint64(size[:])
+		if l > maxJournalField {
+			return nil, n, fmt.Errorf("journal field %s too large: %d bytes", line, l)
+		}
+		data := make([]byte, l+1)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, n, err
+		}
+		n += int64(len(size)) + int64(len(data))
+		addJournalField(entry, string(line), string(data[:l]))
+	}
+}
+
+// addJournalField adds a field to entry, turning it into a list if repeated, as
+// journalctl does in the json format.
+func addJournalField(entry map[string]interface{}, key, value string) {
+	switch v := entry[key].(type) {
+	case nil:
+		entry[key] = value
+	case []interface{}:
+		entry[key] = append(v, value)
+	default:
+		entry[key] = []interface{}{v, value}
+	}
+}
+
+// normalizeJournalEntry turns fields journalctl renders as byte arrays back into
+// text, and adds the severity and timestamp fields.
+func normalizeJournalEntry(entry map[string]interface{}) map[string]interface{} {
+	for k, v := range entry {
+		if b, ok := journalBytes(v); ok {
+			entry[k] = string(b)
+		}
+	}
+	if p, ok := entry["PRIORITY"].(string); ok {
+		if i, err := strconv.Atoi(p); err == nil && i >= 0 && i < len(syslogSeverities) {
+			entry["severity"] = syslogSeverities[i]
+		}
+	}
+	if ts, ok := entry["__REALTIME_TIMESTAMP"].(string); ok {
+		if us, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			entry["timestamp"] = time.UnixMicro(us).UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return entry
+}
+
+// journalBytes tells whether v is a field journalctl couldn't print as text, which
+// it renders as an array of bytes.
+func journalBytes(v interface{}) ([]byte, bool) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, false
+	}
+	b := make([]byte, len(arr))
+	for i, e := range arr {
+		f, ok := e.(float64)
+		if !ok || f < 0 || f > 255 {
+			return nil, false
+		}
+		b[i] = byte(f)
+	}
+	return b, true
+}
+
+func (s *journaldStream) Close() {
+	s.stopEmitting()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+}
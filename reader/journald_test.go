@@ -0,0 +1,155 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func journalBinaryField(key, value string) []byte {
+	var b bytes.Buffer
+	b.WriteString(key + "\n")
+	_ = binary.Write(&b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+	return b.Bytes()
+}
+
+func TestJournaldReader(t *testing.T) {
+	var export bytes.Buffer
+	export.WriteString("__CURSOR=s=1\n__REALTIME_TIMESTAMP=1664625600123456\nPRIORITY=3\n")
+	export.Write(journalBinaryField("MESSAGE", "multi\nline\n"))
+	export.WriteString("TAG=a\nTAG=b\n\n")
+	export.WriteString("__CURSOR=s=2\nPRIORITY=6\nMESSAGE=plain\n\n")
+
+	tests := []struct {
+		name    string
+		content []byte
+		wants   []map[string]interface{}
+	}{
+		{
+			name:    "Export",
+			content: export.Bytes(),
+			wants: []map[string]interface{}{
+				{
+					"__CURSOR":             "s=1",
+					"__REALTIME_TIMESTAMP": "1664625600123456",
+					"PRIORITY":             "3",
+					"MESSAGE":              "multi\nline\n",
+					"TAG":                  []interface{}{"a", "b"},
+					"severity":             "ERROR",
+					"timestamp":            "2022-10-01T12:00:00.123456Z",
+				},
+				{
+					"__CURSOR": "s=2",
+					"PRIORITY": "6",
+					"MESSAGE":  "plain",
+					"severity": "INFO",
+				},
+			},
+		},
+		{
+			name: "JSON",
+			content: []byte(`{"__REALTIME_TIMESTAMP":"1664625600000000","PRIORITY":"4","MESSAGE":[104,105,10]}` + "\n" +
+				`{"PRIORITY":"7","MESSAGE":"debug"}` + "\n"),
+			wants: []map[string]interface{}{
+				{
+					"__REALTIME_TIMESTAMP": "1664625600000000",
+					"PRIORITY":             "4",
+					"MESSAGE":              "hi\n",
+					"severity":             "WARNING",
+					"timestamp":            "2022-10-01T12:00:00Z",
+				},
+				{
+					"PRIORITY": "7",
+					"MESSAGE":  "debug",
+					"severity": "DEBUG",
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "journal")
+			assert.NoError(t, os.WriteFile(fileName, test.content, 0644))
+			r := MakeJournaldReader(fileName, nil)
+			assert.NoError(t, r.StreamInto())
+			defer r.Close()
+
+			var got []map[string]interface{}
+			timeout := time.After(2 * time.Second)
+			for done := false; !done; {
+				select {
+				case rec, ok := <-r.Records():
+					if !ok {
+						done = true
+						break
+					}
+					m := make(map[string]interface{})
+					assert.NoError(t, json.Unmarshal([]byte(rec.Text), &m))
+					got = append(got, m)
+				case <-timeout:
+					t.Fatalf("timeout reading the journal, got %v", got)
+				}
+			}
+			assert.Equal(t, test.wants, got)
+		})
+	}
+}
+
+func TestJournaldReader_MalformedEntry(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "journal")
+	assert.NoError(t, os.WriteFile(fileName, []byte(`{"PRIORITY":"6","MESSAGE":"a"}`+"\n"+
+		`{"PRIORITY":"6","MESS`+"\n"+
+		`{"PRIORITY":"6","MESSAGE":"b"}`+"\n"), 0644))
+	r := MakeJournaldReader(fileName, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+
+	var texts []string
+	timeout := time.After(2 * time.Second)
+	for done := false; !done; {
+		select {
+		case rec, ok := <-r.Records():
+			if !ok {
+				done = true
+				break
+			}
+			texts = append(texts, rec.Text)
+		case <-timeout:
+			t.Fatalf("timeout reading the journal, got %v", texts)
+		}
+	}
+	assert.Equal(t, []string{
+		`{"MESSAGE":"a","PRIORITY":"6","severity":"INFO"}`,
+		`{"PRIORITY":"6","MESS`,
+		`{"MESSAGE":"b","PRIORITY":"6","severity":"INFO"}`,
+	}, texts)
+}
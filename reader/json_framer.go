@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// jsonFramer assembles JSON objects pretty-printed over several lines, emitting each
+// as a compact single line. Malformed chunks are emitted whole as a single record,
+// which fails to parse as one, then the framer resyncs on the next line opening an
+// object at its first column. Lines found outside of any object are bundled likewise.
+type jsonFramer struct {
+	maxLines int
+	timeout  time.Duration
+	pending  *Record
+	text     strings.Builder
+	lines    int
+	last     time.Time
+	// inObject tells whether the pending lines make up an object, rather than stray lines.
+	inObject bool
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+// MakeJSONFramer builds the factory of framers assembling pretty-printed JSON objects.
+// Objects are cut at maxLines lines, and objects or stray lines are emitted once no
+// line was added to them for timeout. Zero maxLines and timeout take the defaults.
+func MakeJSONFramer(maxLines int, timeout time.Duration) func() Framer {
+	if maxLines <= 0 {
+		maxLines = DefaultMaxLines
+	}
+	if timeout <= 0 {
+		timeout = DefaultMultilineTimeout
+	}
+	return func() Framer {
+		return &jsonFramer{
+			maxLines: maxLines,
+			timeout:  timeout,
+		}
+	}
+}
+
+func (f *jsonFramer) Frame(rec *Record) []*Record {
+	var recs []*Record
+	line := strings.TrimRight(rec.Text, "\r\n")
+	for {
+		opens := strings.HasPrefix(strings.TrimLeftFunc(line, unicode.IsSpace), "{")
+		switch {
+		case !f.inObject && opens, f.inObject && f.depth > 0 && strings.HasPrefix(line, "{"):
+			// A new object, possibly cutting a malformed one short.
+			if f.pending != nil {
+				recs = append(recs, f.take())
+			}
+			f.inObject = true
+		case !f.inObject && f.pending != nil && f.lines >= f.maxLines:
+			recs = append(recs, f.take())
+		}
+		f.add(rec, line)
+		if !f.inObject {
+			return recs
+		}
+		end := f.scan(line)
+		if end < 0 {
+			if f.lines >= f.maxLines {
+				recs = append(recs, f.take())
+			}
+			return recs
+		}
+		// The object ends on this line, what's left of it is dealt with on its own.
+		f.trimText(len(line) - end)
+		recs = append(recs, f.take())
+		line = line[end:]
+		// Commas left between the objects of a pretty-printed array are no fragment.
+		if len(strings.TrimFunc(line, isObjectSeparator)) == 0 {
+			return recs
+		}
+	}
+}
+
+// isObjectSeparator tells whether r may be found between objects, such as spaces,
+// line breaks or the commas of an array.
+func isObjectSeparator(r rune) bool {
+	return r == ',' || unicode.IsSpace(r)
+}
+
+// add appends line to the pending record, starting one from rec if there's none.
+func (f *jsonFramer) add(rec *Record, line string) {
+	if f.pending == nil {
+		f.pending = &Record{
+			Source: rec.Source,
+			Time:   rec.Time,
+			Meta:   rec.Meta,
+		}
+	} else {
+		f.text.WriteByte('\n')
+	}
+	f.text.WriteString(line)
+	f.pending.Offset = rec.Offset
+	f.lines++
+	f.last = time.Now()
+}
+
+// trimText drops the last n bytes of the pending text.
+func (f *jsonFramer) trimText(n int) {
+	if n == 0 {
+		return
+	}
+	text := f.text.String()
+	f.text.Reset()
+	f.text.WriteString(text[:len(text)-n])
+}
+
+// scan follows the nesting of line, returning the index right past the end of the
+// object, or -1 if the object doesn't end on this line.
+func (f *jsonFramer) scan(line string) int {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if f.inString {
+			switch {
+			case f.escaped:
+				f.escaped = false
+			case c == '\\':
+				f.escaped = true
+			case c == '"':
+				f.inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			f.inString = true
+		case '{', '[':
+			f.depth++
+		case '}', ']':
+			f.depth--
+			if f.depth <= 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+func (f *jsonFramer) Flush(force bool) []*Record {
+	if f.pending == nil || (!force && time.Since(f.last) < f.timeout) {
+		return nil
+	}
+	return []*Record{f.take()}
+}
+
+// take returns the pending record, compacted if it's a complete object, and resets
+// the framer to look for the next object.
+func (f *jsonFramer) take() *Record {
+	rec := f.pending
+	rec.Text = f.text.String()
+	if f.inObject && f.depth <= 0 {
+		compact := bytes.Buffer{}
+		if err := json.Compact(&compact, []byte(rec.Text)); err == nil {
+			rec.Text = compact.String()
+		}
+	}
+	f.pending = nil
+	f.text.Reset()
+	f.lines = 0
+	f.inObject = false
+	f.depth = 0
+	f.inString = false
+	f.escaped = false
+	return rec
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONFramer_Frame(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     string
+		lineEnd   string
+		maxLines  int
+		wants     []string
+		wantsHeld string
+	}{
+		{
+			name: "Pretty-printed objects",
+			given: `{
+  "level": "info",
+  "msg": "a } in \"quotes\" {",
+  "ctx": {
+    "ids": [1, 2]
+  }
+}
+{"level": "warn"}
+{
+  "level": "error"
+}`,
+			wants: []string{
+				`{"level":"info","msg":"a } in \"quotes\" {","ctx":{"ids":[1,2]}}`,
+				`{"level":"warn"}`,
+				`{"level":"error"}`,
+			},
+		},
+		{
+			name: "Malformed chunk",
+			given: `{
+  "level": "info",
+  "msg": oops,
+}
+not json
+either
+{
+  "level": "info"
+}`,
+			wants: []string{
+				"{\n  \"level\": \"info\",\n  \"msg\": oops,\n}",
+				"not json\neither",
+				`{"level":"info"}`,
+			},
+		},
+		{
+			name: "Resync on unterminated object",
+			given: `{
+  "level": "info",
+  "ctx": {
+{
+  "level": "warn"
+}`,
+			wants: []string{
+				"{\n  \"level\": \"info\",\n  \"ctx\": {",
+				`{"level":"warn"}`,
+			},
+		},
+		{
+			name: "Objects sharing lines",
+			given: `{"a": 1}{"b":
+  2} trailing
+{"c": 3}`,
+			wants: []string{`{"a":1}`, `{"b":2}`, " trailing", `{"c":3}`},
+		},
+		{
+			name: "Array of objects",
+			given: `[
+  {
+    "a": 1
+  },
+  {
+    "b": 2
+  }
+]`,
+			wants:     []string{"[", `{"a":1}`, `{"b":2}`},
+			wantsHeld: "]",
+		},
+		{
+			name: "Newline-terminated lines",
+			given: `{
+  "a": 1
+},
+{"b": 2}  `,
+			lineEnd: "\r\n",
+			wants:   []string{`{"a":1}`, `{"b":2}`},
+		},
+		{
+			name:      "Max lines",
+			maxLines:  3,
+			given:     "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3\n}",
+			wants:     []string{"{\n  \"a\": 1,\n  \"b\": 2,"},
+			wantsHeld: "  \"c\": 3\n}",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := MakeJSONFramer(test.maxLines, time.Hour)()
+			var texts []string
+			for i, line := range strings.Split(test.given, "\n") {
+				rec := &Record{Text: line + test.lineEnd, Source: "src", Offset: int64(i + 1)}
+				for _, rec := range f.Frame(rec) {
+					assert.Equal(t, "src", rec.Source)
+					texts = append(texts, rec.Text)
+				}
+			}
+			assert.Equal(t, test.wants, texts)
+			held := f.Flush(true)
+			if len(test.wantsHeld) > 0 {
+				if assert.Len(t, held, 1) {
+					assert.Equal(t, test.wantsHeld, held[0].Text)
+				}
+			} else {
+				assert.Empty(t, held)
+			}
+		})
+	}
+}
+
+func TestJSONFramer_Flush(t *testing.T) {
+	f := MakeJSONFramer(0, 50*time.Millisecond)()
+	assert.Empty(t, f.Frame(&Record{Text: "{"}))
+	assert.Empty(t, f.Frame(&Record{Text: `  "a": 1`}))
+	assert.Empty(t, f.Flush(false))
+	time.Sleep(60 * time.Millisecond)
+	recs := f.Flush(false)
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, "{\n  \"a\": 1", recs[0].Text)
+	}
+	assert.Equal(t, []*Record{{Text: `{"b":2}`}}, f.Frame(&Record{Text: `{"b": 2}`}))
+}
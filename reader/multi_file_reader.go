@@ -24,58 +24,135 @@ package reader
 
 import (
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/nxadm/tail"
 )
 
 type multiFileStream struct {
-	reader
+	*reader
 	fileNames []string
-	tails     []*tail.Tail
+	tails     map[string]*tail.Tail
+	archives  []io.ReadCloser
+	follow    bool
 	wg        sync.WaitGroup
 	mu        sync.Mutex
 }
 
-// MakeMultiFileReader builds a reader that can stream from multiple files simultaneously
-func MakeMultiFileReader(fileNames []string, strChan chan string) Reader {
-	if strChan == nil {
-		strChan = make(chan string, len(fileNames))
-	}
+// MakeMultiFileReader builds a reader that can stream from multiple files simultaneously.
+// Compressed files may be mixed with plain ones, e.g. a rotated set such as app.log,
+// app.log.1.gz and app.log.2.gz; they're decompressed and read to the end. Among
+// opts, WithOffset is ignored and WithStrChan is superseded by strChan. Without
+// follow, the stream finishes once every file is read.
+func MakeMultiFileReader(fileNames []string, strChan chan string, opts ...Option) Reader {
+	c := makeReaderConfig(opts)
+	s := newMultiFileStream(TypeMultiFile, fileNames, strChan)
+	s.withCheckpoints(c)
+	s.follow = c.follow
+	return s
+}
+
+func newMultiFileStream(readerType Type, fileNames []string, strChan chan string) *multiFileStream {
 	return &multiFileStream{
-		reader: reader{
-			strChan:    strChan,
-			readerType: TypeMultiFile,
-		},
+		reader:    newReader(readerType, strChan),
 		fileNames: fileNames,
+		tails:     make(map[string]*tail.Tail),
+		follow:    true,
 	}
 }
 
 func (s *multiFileStream) StreamInto() error {
-	s.tails = make([]*tail.Tail, 0, len(s.fileNames))
-
 	for _, fileName := range s.fileNames {
-		t, err := tail.TailFile(fileName, tail.Config{Follow: true, Poll: true})
-		if err != nil {
-			s.closeTails()
-			return fmt.Errorf("failed to tail file %s: %w", fileName, err)
+		if err := s.streamFile(fileName); err != nil {
+			// Stops the files already being streamed, compressed ones included.
+			s.Close()
+			return err
 		}
+	}
 
-		s.mu.Lock()
-		s.tails = append(s.tails, t)
-		s.mu.Unlock()
-
-		s.wg.Add(1)
-		go func(t *tail.Tail, fileName string) {
-			defer s.wg.Done()
-			for line := range t.Lines {
-				// TODO: consider adding file name to the line text
-				//s.strChan <- fmt.Sprintf("[%s] %s", fileName, line.Text)
-				s.strChan <- line.Text
-			}
-		}(t, fileName)
+	if !s.follow {
+		go func() {
+			s.wg.Wait()
+			s.finish()
+		}()
 	}
+	return nil
+}
 
+// streamFile starts streaming fileName alongside the files already being streamed.
+func (s *multiFileStream) streamFile(fileName string) error {
+	if c := detectCompression(fileName); c != compressionNone {
+		if err := s.streamCompressed(fileName, c); err != nil {
+			return fmt.Errorf("failed to read file %s: %w", fileName, err)
+		}
+		return nil
+	}
+
+	config := tail.Config{Follow: s.follow, ReOpen: s.follow, Poll: true}
+	rp, resumed := s.resumePoint(fileName)
+	if resumed && rp.offset > 0 {
+		config.Location = &tail.SeekInfo{Whence: 0, Offset: rp.offset}
+	}
+	if s.checkpoints != nil {
+		s.checkpoints.watch(fileName)
+	}
+
+	t, err := tail.TailFile(fileName, config)
+	if err != nil {
+		return fmt.Errorf("failed to tail file %s: %w", fileName, err)
+	}
+
+	s.mu.Lock()
+	s.tails[fileName] = t
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.streamRotated(rp); err != nil && s.onError != nil {
+			s.onError(err)
+		}
+		for line := range t.Lines {
+			s.emit(&Record{Text: line.Text, Source: fileName, Offset: line.SeekInfo.Offset, Time: line.Time})
+		}
+	}()
+	return nil
+}
+
+// stopFile stops tailing fileName. It returns false if the file wasn't being tailed.
+func (s *multiFileStream) stopFile(fileName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tails[fileName]
+	if ok {
+		delete(s.tails, fileName)
+		t.Kill(fmt.Errorf("file %s was removed", fileName))
+	}
+	return ok
+}
+
+func (s *multiFileStream) streamCompressed(fileName string, c compression) error {
+	rc, err := openDecompressed(fileName, c)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.archives = append(s.archives, rc)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := readLines(rc, 0, s.done, func(line string, offset int64) bool {
+			return s.emit(&Record{Text: line, Source: fileName, Offset: offset})
+		})
+		if err != nil && s.onError != nil {
+			s.onError(fmt.Errorf("failed to read file %s: %w", fileName, err))
+		}
+	}()
 	return nil
 }
 
@@ -89,7 +166,14 @@ func (s *multiFileStream) closeTails() {
 }
 
 func (s *multiFileStream) Close() {
+	s.stopEmitting()
 	s.closeTails()
 	s.wg.Wait()
-	close(s.strChan)
+	s.mu.Lock()
+	for _, rc := range s.archives {
+		_ = rc.Close()
+	}
+	s.archives = nil
+	s.mu.Unlock()
+	s.closeRecords()
 }
@@ -26,6 +26,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -145,3 +148,119 @@ func TestMultiFileReader(t *testing.T) {
 		t.Errorf("Expected 2 lines from file2, got %d", file2Count)
 	}
 }
+
+func TestMultiFileReader_MixedCompression(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "app.log")
+	rotated1 := filepath.Join(tempDir, "app.log.1.gz")
+	rotated2 := filepath.Join(tempDir, "app.log.2.gz")
+	if err := ioutil.WriteFile(live, []byte("live line1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write live file: %v", err)
+	}
+	writeGzip(t, rotated1, "rotated1 line1\nrotated1 line2\n")
+	writeGzip(t, rotated2, "rotated2 line1\n")
+
+	reader := MakeMultiFileReader([]string{live, rotated1, rotated2}, make(chan string, 10))
+	if err := reader.StreamInto(); err != nil {
+		t.Fatalf("Failed to start streaming: %v", err)
+	}
+	lines := collectLines(t, reader, 4)
+	reader.Close()
+
+	sort.Strings(lines)
+	expected := []string{"live line1", "rotated1 line1", "rotated1 line2", "rotated2 line1"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+}
+
+func TestMultiFileReader_StopsStartedFilesOnError(t *testing.T) {
+	tempDir := t.TempDir()
+	rotated := filepath.Join(tempDir, "app.log.1.gz")
+	broken := filepath.Join(tempDir, "app.log.2.gz")
+	writeGzip(t, rotated, strings.Repeat("rotated line\n", 1000))
+	if err := ioutil.WriteFile(broken, []byte("\x1f\x8b\x00not gzip at all"), 0644); err != nil {
+		t.Fatalf("Failed to write broken file: %v", err)
+	}
+
+	reader := MakeMultiFileReader([]string{rotated, broken}, nil)
+	if err := reader.StreamInto(); err == nil {
+		t.Fatal("Expected an error for the broken file")
+	}
+	drainRecords(t, reader)
+	reader.Close()
+}
+
+func TestMultiFileReader_Records(t *testing.T) {
+	tempDir := t.TempDir()
+	file1Path := filepath.Join(tempDir, "api.log")
+	file2Path := filepath.Join(tempDir, "worker.log.gz")
+	if err := ioutil.WriteFile(file1Path, []byte("api line1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write to file1: %v", err)
+	}
+	writeGzip(t, file2Path, "worker line1\n")
+
+	reader := MakeMultiFileReader([]string{file1Path, file2Path}, nil)
+	if err := reader.StreamInto(); err != nil {
+		t.Fatalf("Failed to start streaming: %v", err)
+	}
+	defer reader.Close()
+
+	sources := make(map[string]string)
+	timeout := time.After(2 * time.Second)
+	for len(sources) < 2 {
+		select {
+		case rec := <-reader.Records():
+			sources[rec.Text] = rec.Source
+		case <-timeout:
+			t.Fatalf("Timeout waiting for records, got %v", sources)
+		}
+	}
+	expected := map[string]string{"api line1": file1Path, "worker line1": file2Path}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Expected %v, got %v", expected, sources)
+	}
+}
+
+func TestMultiFileReader_NoFollow(t *testing.T) {
+	tempDir := t.TempDir()
+	live := filepath.Join(tempDir, "app.log")
+	rotated := filepath.Join(tempDir, "app.log.1.gz")
+	if err := ioutil.WriteFile(live, []byte("live line1\nlive line2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write live file: %v", err)
+	}
+	writeGzip(t, rotated, "rotated line1\n")
+
+	events := make(chan Event, 1)
+	reader := MakeMultiReader([]string{live, rotated}, nil, WithFollow(false))
+	reader.EventNotifier(func(e Event) {
+		events <- e
+	})
+	if err := reader.StreamInto(); err != nil {
+		t.Fatalf("Failed to start streaming: %v", err)
+	}
+	defer reader.Close()
+
+	var lines []string
+	timeout := time.After(3 * time.Second)
+	for done := false; !done; {
+		select {
+		case rec, ok := <-reader.Records():
+			if !ok {
+				done = true
+				break
+			}
+			lines = append(lines, rec.Text)
+		case <-timeout:
+			t.Fatalf("Timeout waiting for the stream to end, got %v", lines)
+		}
+	}
+	sort.Strings(lines)
+	expected := []string{"live line1", "live line2", "rotated line1"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+	if e := <-events; e.Type != EventEndOfStream || e.Lines != 3 {
+		t.Errorf("Expected end of stream after 3 lines, got %+v", e)
+	}
+}
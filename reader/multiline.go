@@ -0,0 +1,134 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxLines is how many lines a multi-line record is cut at by default.
+	DefaultMaxLines = 500
+	// DefaultMultilineTimeout is how long a multi-line record waits by default for
+	// its next line before being emitted as is.
+	DefaultMultilineTimeout = time.Second
+)
+
+// multilineFramer joins the lines making up a single record, such as a log line
+// followed by its stack trace. Joined lines are separated by new lines.
+type multilineFramer struct {
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+	maxLines     int
+	timeout      time.Duration
+	pending      *Record
+	text         strings.Builder
+	lines        int
+	last         time.Time
+}
+
+// MakeMultilineFramer builds the factory of framers joining multi-line records. A line
+// is joined to the record before it if it matches continuation, or if start is given
+// and it doesn't match start. Records are cut at maxLines lines and emitted once no
+// line was joined to them for timeout. Zero maxLines and timeout take the defaults.
+func MakeMultilineFramer(start, continuation string, maxLines int, timeout time.Duration) (func() Framer, error) {
+	if len(start) == 0 && len(continuation) == 0 {
+		return nil, errors.New("multi-line joining requires a start or a continuation pattern")
+	}
+	var startRe, continuationRe *regexp.Regexp
+	var err error
+	if len(start) > 0 {
+		if startRe, err = regexp.Compile(start); err != nil {
+			return nil, fmt.Errorf("invalid multi-line start pattern: %w", err)
+		}
+	}
+	if len(continuation) > 0 {
+		if continuationRe, err = regexp.Compile(continuation); err != nil {
+			return nil, fmt.Errorf("invalid multi-line continuation pattern: %w", err)
+		}
+	}
+	if maxLines <= 0 {
+		maxLines = DefaultMaxLines
+	}
+	if timeout <= 0 {
+		timeout = DefaultMultilineTimeout
+	}
+	return func() Framer {
+		return &multilineFramer{
+			start:        startRe,
+			continuation: continuationRe,
+			maxLines:     maxLines,
+			timeout:      timeout,
+		}
+	}, nil
+}
+
+// joins tells whether line belongs to the record before it.
+func (f *multilineFramer) joins(line string) bool {
+	if f.continuation != nil && f.continuation.MatchString(line) {
+		return true
+	}
+	return f.start != nil && !f.start.MatchString(line)
+}
+
+func (f *multilineFramer) Frame(rec *Record) []*Record {
+	var recs []*Record
+	if f.pending != nil && !f.joins(rec.Text) {
+		recs = append(recs, f.take())
+	}
+	if f.pending == nil {
+		f.pending = rec
+		f.text.WriteString(rec.Text)
+	} else {
+		f.text.WriteByte('\n')
+		f.text.WriteString(rec.Text)
+		f.pending.Offset = rec.Offset
+	}
+	f.lines++
+	f.last = time.Now()
+	if f.lines >= f.maxLines {
+		recs = append(recs, f.take())
+	}
+	return recs
+}
+
+func (f *multilineFramer) Flush(force bool) []*Record {
+	if f.pending == nil || (!force && time.Since(f.last) < f.timeout) {
+		return nil
+	}
+	return []*Record{f.take()}
+}
+
+// take returns the pending record, ready to be emitted.
+func (f *multilineFramer) take() *Record {
+	rec := f.pending
+	rec.Text = f.text.String()
+	f.pending = nil
+	f.text.Reset()
+	f.lines = 0
+	return rec
+}
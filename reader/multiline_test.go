@@ -0,0 +1,173 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultilineFramer_Frame(t *testing.T) {
+	tests := []struct {
+		name         string
+		start        string
+		continuation string
+		maxLines     int
+		given        []string
+		wants        []string
+		wantsHeld    string
+	}{
+		{
+			name:  "Java stack trace",
+			start: `^\d{4}-\d{2}-\d{2}`,
+			given: []string{
+				"2026-10-01 12:00:00 ERROR request failed",
+				"java.lang.IllegalStateException: boom",
+				"\tat com.acme.App.run(App.java:42)",
+				"2026-10-01 12:00:01 INFO recovered",
+			},
+			wants: []string{
+				"2026-10-01 12:00:00 ERROR request failed\n" +
+					"java.lang.IllegalStateException: boom\n" +
+					"\tat com.acme.App.run(App.java:42)",
+			},
+			wantsHeld: "2026-10-01 12:00:01 INFO recovered",
+		},
+		{
+			name:         "Python traceback",
+			continuation: `^(\s|Traceback|\w+Error:)`,
+			given: []string{
+				"ERROR:root:failed",
+				"Traceback (most recent call last):",
+				`  File "app.py", line 1, in <module>`,
+				"ZeroDivisionError: division by zero",
+				"INFO:root:next",
+			},
+			wants: []string{
+				"ERROR:root:failed\n" +
+					"Traceback (most recent call last):\n" +
+					`  File "app.py", line 1, in <module>` + "\n" +
+					"ZeroDivisionError: division by zero",
+			},
+			wantsHeld: "INFO:root:next",
+		},
+		{
+			name:         "Continuation overrides start",
+			start:        `^\S`,
+			continuation: `^Caused by:`,
+			given:        []string{"first", "  more", "Caused by: x", "second"},
+			wants:        []string{"first\n  more\nCaused by: x"},
+			wantsHeld:    "second",
+		},
+		{
+			name:     "Max lines",
+			start:    `^START`,
+			maxLines: 2,
+			given:    []string{"START 1", "a", "b", "START 2"},
+			wants:    []string{"START 1\na", "b"},
+			// The cut off line starts a record of its own
+			wantsHeld: "START 2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newFramer, err := MakeMultilineFramer(test.start, test.continuation, test.maxLines, time.Hour)
+			assert.NoError(t, err)
+			f := newFramer()
+			var texts []string
+			for i, line := range test.given {
+				for _, rec := range f.Frame(&Record{Text: line, Offset: int64(i + 1)}) {
+					texts = append(texts, rec.Text)
+				}
+			}
+			assert.Equal(t, test.wants, texts)
+			assert.Empty(t, f.Flush(false))
+			held := f.Flush(true)
+			if assert.Len(t, held, 1) {
+				assert.Equal(t, test.wantsHeld, held[0].Text)
+				assert.Equal(t, int64(len(test.given)), held[0].Offset)
+			}
+		})
+	}
+}
+
+func TestMultilineFramer_Flush(t *testing.T) {
+	newFramer, err := MakeMultilineFramer(`^\S`, "", 0, 50*time.Millisecond)
+	assert.NoError(t, err)
+	f := newFramer()
+	assert.Empty(t, f.Frame(&Record{Text: "first"}))
+	assert.Empty(t, f.Frame(&Record{Text: " more"}))
+	assert.Empty(t, f.Flush(false))
+	time.Sleep(60 * time.Millisecond)
+	recs := f.Flush(false)
+	if assert.Len(t, recs, 1) {
+		assert.Equal(t, "first\n more", recs[0].Text)
+	}
+	assert.Empty(t, f.Flush(true))
+}
+
+func TestMakeMultilineFramer(t *testing.T) {
+	_, err := MakeMultilineFramer("", "", 0, 0)
+	assert.Error(t, err)
+	_, err = MakeMultilineFramer("(", "", 0, 0)
+	assert.Error(t, err)
+	_, err = MakeMultilineFramer("", "(", 0, 0)
+	assert.Error(t, err)
+}
+
+func TestMultilineFramer_Sources(t *testing.T) {
+	dir := t.TempDir()
+	file1 := filepath.Join(dir, "app1.log")
+	file2 := filepath.Join(dir, "app2.log")
+	assert.NoError(t, os.WriteFile(file1, []byte("START a\n a1\n a2\n"), 0644))
+	assert.NoError(t, os.WriteFile(file2, []byte("START b\n b1\nSTART c\n"), 0644))
+
+	newFramer, err := MakeMultilineFramer(`^START`, "", 0, time.Hour)
+	assert.NoError(t, err)
+	r := Frame(MakeMultiFileReader([]string{file1, file2}, nil, WithFollow(false)), newFramer)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+
+	texts := make(map[string][]string)
+	timeout := time.After(3 * time.Second)
+	for done := false; !done; {
+		select {
+		case rec, ok := <-r.Records():
+			if !ok {
+				done = true
+				break
+			}
+			texts[rec.Source] = append(texts[rec.Source], rec.Text)
+		case <-timeout:
+			t.Fatalf("timeout waiting for the stream to end, got %v", texts)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		file1: {"START a\n a1\n a2"},
+		file2: {"START b\n b1", "START c"},
+	}, texts)
+}
@@ -25,13 +25,15 @@ package reader
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 )
 
 type readPipeStream struct {
-	reader
-	stop bool
+	*reader
+	follow bool
 }
 
 func (s *readPipeStream) StreamInto() error {
@@ -46,17 +48,39 @@ func (s *readPipeStream) StreamInto() error {
 	reader := bufio.NewReader(os.Stdin)
 
 	go func() {
-		for !s.stop {
+		// The stdin can't be interrupted, so the records channel is only closed
+		// once the pending read returns.
+		defer s.closeRecords()
+		var offset int64
+		var line string
+		for {
 			str, err := reader.ReadString('\n')
+			// Lines are emitted whole, so a partial one waits for the rest of it.
+			line += str
+			if err == io.EOF && !s.follow {
+				if len(line) > 0 {
+					s.emit(&Record{Text: strings.TrimRight(line, "\r\n"), Offset: offset + int64(len(line))})
+				}
+				s.finish()
+				return
+			}
 			if err != nil {
-				time.Sleep(time.Second)
+				select {
+				case <-s.done:
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			offset += int64(len(line))
+			if !s.emit(&Record{Text: strings.TrimRight(line, "\r\n"), Offset: offset}) {
+				return
 			}
-			s.strChan <- str
+			line = ""
 		}
 	}()
 	return nil
 }
 func (s *readPipeStream) Close() {
-	s.stop = true
-	close(s.strChan)
+	s.stopEmitting()
 }
@@ -41,7 +41,7 @@ func TestReadPipeStream_StreamInto(t *testing.T) {
 		// Routine to write file lines
 		before := time.Now().UnixMilli()
 		streamReceiver := make(chan string, 1)
-		reader := MakeReader("", streamReceiver)
+		reader := MakeReader("", WithStrChan(streamReceiver))
 		r, w, err := os.Pipe()
 		os.Stdin = r
 		assert.NoError(t, err)
@@ -56,7 +56,7 @@ func TestReadPipeStream_StreamInto(t *testing.T) {
 		var lines []string
 		_ = reader.StreamInto()
 		for {
-			line, ok := <-streamReceiver
+			line, ok := <-reader.ChanReader()
 			if !ok {
 				break
 			}
@@ -70,3 +70,31 @@ func TestReadPipeStream_StreamInto(t *testing.T) {
 		assert.True(t, diff >= int64(1))
 	})
 }
+
+func TestReadPipeStream_Follow(t *testing.T) {
+	oldStdIn := os.Stdin
+	defer func() {
+		os.Stdin = oldStdIn
+	}()
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	os.Stdin = r
+
+	reader := MakeReader("", WithFollow(true))
+	assert.NoError(t, reader.StreamInto())
+	defer reader.Close()
+	go func() {
+		_, _ = w.WriteString("line 1\npart")
+		_ = w.Close()
+	}()
+
+	rec := <-reader.Records()
+	assert.Equal(t, "line 1", rec.Text)
+	assert.Equal(t, uint64(1), rec.Seq)
+	// Past the end of the input, the partial line waits and nothing else is emitted.
+	select {
+	case rec := <-reader.Records():
+		t.Fatalf("Unexpected record %+v", rec)
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
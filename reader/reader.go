@@ -22,10 +22,58 @@ THE SOFTWARE.
 
 package reader
 
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Record is a single streamed log line along with metadata about where and when it
+// was read.
+type Record struct {
+	// Text is the raw line, as read from the source.
+	Text string
+	// Source identifies where the line came from, e.g. the file name. It's empty
+	// for sources that can't be told apart, such as the stdin.
+	Source string
+	// Offset is the byte offset right past the line in its source, i.e. where reading
+	// would resume with the next line. It's zero for sources without byte offsets.
+	Offset int64
+	// Time is when the line was received.
+	Time time.Time
+	// Seq numbers the records of a reader in the order they're emitted, starting at 1.
+	Seq uint64
+	// Meta holds pseudo-fields about the line found while reading it, such as MetaTime
+	// and MetaStream for container logs, keyed by field name.
+	Meta map[string]string
+}
+
 type reader struct {
+	recChan    chan *Record
 	strChan    chan string
+	done       chan struct{}
+	seq        atomic.Uint64
 	readerType Type
 	onError    func(err error)
+	onEvent    func(e Event)
+	// checkpoints is nil unless checkpoints are to be saved.
+	checkpoints *checkpointer
+	resume      bool
+	adaptOnce   sync.Once
+	doneOnce    sync.Once
+	closeOnce   sync.Once
+}
+
+// newReader builds the base reader. Records are only adapted into strChan once
+// ChanReader is called, so as not to compete with a consumer of Records. A string
+// channel is created then if strChan is nil.
+func newReader(readerType Type, strChan chan string) *reader {
+	return &reader{
+		recChan:    make(chan *Record, 1),
+		strChan:    strChan,
+		done:       make(chan struct{}),
+		readerType: readerType,
+	}
 }
 
 type Type = int64
@@ -33,10 +81,25 @@ type Type = int64
 type Option func(*readerConfig)
 
 type readerConfig struct {
-	strChan chan string
-	offset  int64
+	strChan     chan string
+	offset      int64
+	follow      bool
+	checkpoints bool
+	resume      bool
+}
+
+func makeReaderConfig(opts []Option) readerConfig {
+	c := readerConfig{
+		offset: 0,
+		follow: true,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
 }
 
+// WithStrChan sets the channel ChanReader feeds, rather than one of its own.
 func WithStrChan(strChan chan string) Option {
 	return func(c *readerConfig) {
 		c.strChan = strChan
@@ -49,61 +112,196 @@ func WithOffset(offset int64) Option {
 	}
 }
 
+// WithFollow sets whether files keep being followed for new lines, which is the
+// default. Without follow, the reader stops at the end of the input, closing its
+// channel and sending an EventEndOfStream.
+func WithFollow(follow bool) Option {
+	return func(c *readerConfig) {
+		c.follow = follow
+	}
+}
+
 const (
 	TypeFile = Type(iota)
 	TypePipe
 	TypeMultiFile
+	TypeGCP
+	TypeWatch
+	TypeSyslog
+	TypeExec
+	TypeJournald
 )
 
-// MakeReader builds a continues file/pipe streamer used to feed the logger. If
-// fileName is not provided, it will attempt to consume the input from the stdin.
-func MakeReader(fileName string, opts ...Option) Reader {
-	c := readerConfig{
-		strChan: make(chan string, 1),
-		offset:  0,
+// WithCheckpoints saves a checkpoint under ~/.loggo for every plain file read, once
+// the reader is closed or finished, so that a later session can resume from there.
+func WithCheckpoints() Option {
+	return func(c *readerConfig) {
+		c.checkpoints = true
 	}
+}
 
-	for _, opt := range opts {
-		opt(&c)
+// WithResume resumes plain files from their last checkpoint, if they have one,
+// overriding WithOffset. Lines written after the checkpoint are found even if the
+// file has since been rotated. New checkpoints are saved as with WithCheckpoints.
+func WithResume() Option {
+	return func(c *readerConfig) {
+		c.checkpoints = true
+		c.resume = true
 	}
+}
+
+// MakeReader builds a continues file/pipe streamer used to feed the logger. If
+// fileName is not provided, it will attempt to consume the input from the stdin.
+// Gzip, zstd and bzip2 compressed files are decompressed on the fly and read to
+// the end instead of being followed.
+func MakeReader(fileName string, opts ...Option) Reader {
+	c := makeReaderConfig(opts)
 
 	if len(fileName) > 0 {
+		if comp := detectCompression(fileName); comp != compressionNone {
+			// Offsets into decompressed content can't be resumed from, hence no checkpoints.
+			return &compressedFileStream{
+				reader:      newReader(TypeFile, c.strChan),
+				fileName:    fileName,
+				compression: comp,
+				offset:      c.offset,
+			}
+		}
 		return &fileStream{
-			reader: reader{
-				strChan:    c.strChan,
-				readerType: TypeFile,
-			},
+			reader:   newReader(TypeFile, c.strChan).withCheckpoints(c),
 			fileName: fileName,
 			offset:   c.offset,
+			follow:   c.follow,
 		}
 	}
 
 	return &readPipeStream{
-		reader: reader{
-			strChan:    c.strChan,
-			readerType: TypePipe,
-		},
+		reader: newReader(TypePipe, c.strChan),
+		follow: c.follow,
+	}
+}
+
+func MakeMultiReader(fileNames []string, strChan chan string, opts ...Option) Reader {
+	opts = append([]Option{WithStrChan(strChan)}, opts...)
+	if len(fileNames) <= 1 {
+		fileName := ""
+		if len(fileNames) == 1 {
+			fileName = fileNames[0]
+		}
+		return MakeReader(fileName, opts...)
+	}
+
+	return MakeMultiFileReader(fileNames, strChan, opts...)
+}
+
+func (s *reader) withCheckpoints(c readerConfig) *reader {
+	if c.checkpoints {
+		s.checkpoints = newCheckpointer()
+	}
+	s.resume = c.resume
+	return s
+}
+
+// resumePoint tells where to resume fileName from, if resuming and if there's a
+// checkpoint for it. Otherwise, it returns false.
+func (s *reader) resumePoint(fileName string) (resumePoint, bool) {
+	if !s.resume {
+		return resumePoint{}, false
+	}
+	cp, err := LoadCheckpoint(fileName)
+	if err != nil || cp == nil {
+		return resumePoint{}, false
+	}
+	return cp.resolve(), true
+}
+
+// emit stamps rec and hands it over to the consumer. It returns false if the reader
+// has been closed in the meantime, in which case rec is dropped.
+func (s *reader) emit(rec *Record) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
 	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Seq = s.seq.Add(1)
+	select {
+	case <-s.done:
+		return false
+	case s.recChan <- rec:
+		if s.checkpoints != nil {
+			s.checkpoints.track(rec)
+		}
+		return true
+	}
+}
+
+// stopEmitting signals producers to stop, unblocking any pending emit.
+func (s *reader) stopEmitting() {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
 }
 
-func MakeMultiReader(fileNames []string, strChan chan string) Reader {
-	if len(fileNames) == 0 {
-		return &readPipeStream{
-			reader: reader{
-				strChan:    strChan,
-				readerType: TypePipe,
-			},
+// closeRecords closes the records channel and saves checkpoints on a best effort
+// basis. It must only be called once no producer can emit anymore.
+func (s *reader) closeRecords() {
+	s.closeOnce.Do(func() {
+		if s.checkpoints != nil {
+			_ = s.checkpoints.save()
 		}
+		close(s.recChan)
+	})
+}
+
+// finish ends a stream that ran out of input, closing the records channel and
+// reporting how many lines were streamed. Nothing is reported if the reader was
+// closed beforehand.
+func (s *reader) finish() {
+	select {
+	case <-s.done:
+		return
+	default:
 	}
+	// Notified first, so that wrapping readers know the end is no Close.
+	s.notify(Event{Type: EventEndOfStream, Lines: s.seq.Load()})
+	s.closeRecords()
+}
 
-	if len(fileNames) == 1 {
-		return MakeReader(fileNames[0])
+// adapt forwards the text of every record into the string channel, closing it
+// once the reader is closed.
+func (s *reader) adapt() {
+	defer close(s.strChan)
+	for {
+		select {
+		case <-s.done:
+			return
+		case rec, ok := <-s.recChan:
+			if !ok {
+				return
+			}
+			select {
+			case <-s.done:
+				return
+			case s.strChan <- rec.Text:
+			}
+		}
 	}
+}
 
-	return MakeMultiFileReader(fileNames, strChan)
+func (s *reader) Records() <-chan *Record {
+	return s.recChan
 }
 
 func (s *reader) ChanReader() <-chan string {
+	s.adaptOnce.Do(func() {
+		if s.strChan == nil {
+			s.strChan = make(chan string, 1)
+		}
+		go s.adapt()
+	})
 	return s.strChan
 }
 
@@ -111,17 +309,35 @@ func (s *reader) ErrorNotifier(onError func(err error)) {
 	s.onError = onError
 }
 
+func (s *reader) EventNotifier(onEvent func(e Event)) {
+	s.onEvent = onEvent
+}
+
+// notify reports e to the registered event callback, if any.
+func (s *reader) notify(e Event) {
+	if s.onEvent != nil {
+		s.onEvent(e)
+	}
+}
+
 func (s *reader) Type() Type {
 	return s.readerType
 }
 
 type Reader interface {
-	// StreamInto feeds the strChan channel for every streamed line.
+	// StreamInto starts streaming, feeding the records channel for every streamed line.
 	StreamInto() error
 	// Close finalises and invalidates this stream reader.
 	Close()
-	// ChanReader returns the outbound channel reader
+	// Records returns the outbound channel of records, tagged with their source.
+	Records() <-chan *Record
+	// ChanReader returns the outbound channel reader, yielding the bare text of each
+	// record, the one given with WithStrChan if any. Records are only fed into it once
+	// it's called, and only one of Records or ChanReader should be consumed.
 	ChanReader() <-chan string
 	// ErrorNotifier registers a callback func that's called upon fatal streaming log.
 	ErrorNotifier(onError func(err error))
+	// EventNotifier registers a callback func that's called upon stream events, such as
+	// files being picked up or dropped.
+	EventNotifier(onEvent func(e Event))
 }
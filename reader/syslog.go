@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const syslogNil = "-"
+
+var (
+	syslogFacilities = []string{
+		"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+		"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+		"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+	}
+	// syslogSeverities follow the level names used elsewhere, so that they're coloured
+	// the same way.
+	syslogSeverities = []string{
+		"EMERGENCY", "ALERT", "CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG",
+	}
+)
+
+// parseSyslog parses an RFC 5424 or RFC 3164 message into its fields. Messages with
+// no priority are kept whole as the message. Years missing from RFC 3164 timestamps
+// are taken from now.
+func parseSyslog(msg string, now time.Time) map[string]interface{} {
+	msg = strings.TrimRight(msg, "\r\n\x00")
+	m := make(map[string]interface{})
+	pri, rest, ok := syslogPriority(msg)
+	if !ok {
+		m["message"] = msg
+		return m
+	}
+	m["facility"] = syslogFacilities[pri/8]
+	m["severity"] = syslogSeverities[pri%8]
+	if strings.HasPrefix(rest, "1 ") {
+		if parseRFC5424(rest[2:], m) {
+			return m
+		}
+	}
+	parseRFC3164(rest, now, m)
+	return m
+}
+
+func syslogPriority(msg string) (int, string, bool) {
+	if !strings.HasPrefix(msg, "<") {
+		return 0, msg, false
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return 0, msg, false
+	}
+	pri, err := strconv.Atoi(msg[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, msg, false
+	}
+	return pri, msg[end+1:], true
+}
+
+// parseRFC5424 parses what follows "<PRI>1 ", i.e.
+// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(s string, m map[string]interface{}) bool {
+	fields := strings.SplitN(s, " ", 6)
+	if len(fields) < 6 {
+		return false
+	}
+	if fields[0] != syslogNil {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return false
+		}
+		m["timestamp"] = ts.Format(time.RFC3339Nano)
+	}
+	for i, name := range []string{"hostname", "appName", "procId", "msgId"} {
+		if v := fields[i+1]; v != syslogNil {
+			m[name] = v
+		}
+	}
+	sd, msg, err := parseStructuredData(fields[5])
+	if err != nil {
+		return false
+	}
+	if len(sd) > 0 {
+		m["structuredData"] = sd
+	}
+	msg = strings.TrimPrefix(msg, "\ufeff")
+	if len(msg) > 0 {
+		m["message"] = msg
+	}
+	return true
+}
+
+// parseStructuredData parses the leading STRUCTURED-DATA of s, returning it along
+// with the message that follows.
+func parseStructuredData(s string) (map[string]interface{}, string, error) {
+	if strings.HasPrefix(s, syslogNil) {
+		return nil, strings.TrimPrefix(s[1:], " "), nil
+	}
+	sd := make(map[string]interface{})
+	for strings.HasPrefix(s, "[") {
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated structured data")
+		}
+		id := s[1:end]
+		params := make(map[string]interface{})
+		s = s[end:]
+		for strings.HasPrefix(s, " ") {
+			eq := strings.Index(s, `="`)
+			if eq < 0 {
+				return nil, "", fmt.Errorf("invalid structured data param in %s", id)
+			}
+			name := s[1:eq]
+			var value strings.Builder
+			i := eq + 2
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, "", fmt.Errorf("unterminated structured data param %s", name)
+			}
+			params[name] = value.String()
+			s = s[i+1:]
+		}
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", fmt.Errorf("unterminated structured data %s", id)
+		}
+		sd[id] = params
+		s = s[1:]
+	}
+	return sd, strings.TrimPrefix(s, " "), nil
+}
+
+// parseRFC3164 parses what follows "<PRI>", i.e. "Mmm dd hh:mm:ss HOSTNAME TAG: MSG",
+// leniently, as implementations vary a lot.
+func parseRFC3164(s string, now time.Time, m map[string]interface{}) {
+	const layout = time.Stamp
+	if len(s) >= len(layout) {
+		if ts, err := time.ParseInLocation(layout, s[:len(layout)], now.Location()); err == nil {
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// Around new year, the message may well be from the year before.
+			if ts.After(now.AddDate(0, 0, 1)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			m["timestamp"] = ts.Format(time.RFC3339Nano)
+			s = strings.TrimPrefix(s[len(layout):], " ")
+			if sp := strings.IndexByte(s, ' '); sp > 0 && !strings.HasSuffix(s[:sp], ":") {
+				m["hostname"] = s[:sp]
+				s = s[sp+1:]
+			}
+		}
+	}
+	if end := strings.IndexAny(s, "[: "); end > 0 && end <= 48 {
+		tag, rest := s[:end], s[end:]
+		if strings.HasPrefix(rest, "[") {
+			if pidEnd := strings.IndexByte(rest, ']'); pidEnd > 0 {
+				m["procId"] = rest[1:pidEnd]
+				rest = rest[pidEnd+1:]
+			}
+		}
+		if strings.HasPrefix(rest, ":") {
+			m["appName"] = tag
+			s = strings.TrimPrefix(rest[1:], " ")
+		}
+	}
+	if len(s) > 0 {
+		m["message"] = s
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSyslogMessage bounds datagrams and octet counted frames.
+const maxSyslogMessage = 64 * 1024
+
+type syslogStream struct {
+	*reader
+	network  string
+	address  string
+	listener net.Listener
+	conn     net.PacketConn
+	conns    map[net.Conn]bool
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// MakeSyslogReader builds a reader listening for RFC 5424 and RFC 3164 syslog messages
+// at address, given as udp://host:port, tcp://host:port, unix:///path/to/socket
+// (stream) or unixgram:///path/to/socket. Every message is turned into a JSON record
+// with its facility, severity, timestamp, hostname, appName, procId, msgId,
+// structuredData and message fields, and tagged with the sender as its source.
+func MakeSyslogReader(address string, strChan chan string) Reader {
+	return &syslogStream{
+		reader:  newReader(TypeSyslog, strChan),
+		address: address,
+		conns:   make(map[net.Conn]bool),
+	}
+}
+
+// SplitSyslogAddress splits a syslog listening address into its network and address.
+func SplitSyslogAddress(address string) (string, string, error) {
+	network, addr, ok := strings.Cut(address, "://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid syslog address %s: expected <network>://<address>", address)
+	}
+	switch network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return "", "", fmt.Errorf("invalid syslog network %s: must be udp, tcp, unix or unixgram", network)
+	}
+	return network, addr, nil
+}
+
+func (s *syslogStream) StreamInto() error {
+	var err error
+	s.network, s.address, err = SplitSyslogAddress(s.address)
+	if err != nil {
+		return err
+	}
+	switch s.network {
+	case "udp", "unixgram":
+		s.conn, err = net.ListenPacket(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.wg.Add(1)
+		go s.readPackets()
+	default:
+		s.listener, err = net.Listen(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.wg.Add(1)
+		go s.accept()
+	}
+	return nil
+}
+
+// addr returns the address actually listened at.
+func (s *syslogStream) addr() net.Addr {
+	if s.conn != nil {
+		return s.conn.LocalAddr()
+	}
+	return s.listener.Addr()
+}
+
+func (s *syslogStream) readPackets() {
+	defer s.wg.Done()
+	buf := make([]byte, maxSyslogMessage)
+	for {
+		n, from, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		source := ""
+		if from != nil {
+			source = from.String()
+		}
+		if !s.emitMessage(string(buf[:n]), source) {
+			return
+		}
+	}
+}
+
+func (s *syslogStream) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if !s.track(conn) {
+			_ = conn.Close()
+			return
+		}
+		go s.readStream(conn)
+	}
+}
+
+// track registers conn to be read, unless the reader has been closed in the meantime,
+// in which case Close may have already closed the others.
+func (s *syslogStream) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	s.conns[conn] = true
+	s.wg.Add(1)
+	return true
+}
+
+// readStream reads messages framed either by octet counting or by new lines, as
+// described in RFC 6587.
+func (s *syslogStream) readStream(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	source := conn.RemoteAddr().String()
+	if len(source) == 0 || source == "@" {
+		source = ""
+	}
+	br := bufio.NewReader(conn)
+	for {
+		first, err := br.Peek(1)
+		if err != nil {
+			return
+		}
+		var msg string
+		if first[0] >= '1' && first[0] <= '9' {
+			msg, err = readOctetCounted(br)
+		} else {
+			msg, err = br.ReadString('\n')
+		}
+		if len(msg) > 0 && !s.emitMessage(msg, source) {
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readOctetCounted(br *bufio.Reader) (string, error) {
+	size, err := br.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	if err != nil || n > maxSyslogMessage {
+		return "", fmt.Errorf("invalid syslog frame size %q", size)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (s *syslogStream) emitMessage(msg, source string) bool {
+	b, err := json.Marshal(parseSyslog(msg, time.Now()))
+	if err != nil {
+		return true
+	}
+	return s.emit(&Record{Text: string(b), Source: source})
+}
+
+// fail reports err, unless it's due to the reader being closed.
+func (s *syslogStream) fail(err error) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+func (s *syslogStream) Close() {
+	// Stopped first, for connections accepted from then on not to be tracked.
+	s.stopEmitting()
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	if s.listener != nil {
+		_ = s.listener.Close()
+	}
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	if s.network == "unix" || s.network == "unixgram" {
+		_ = os.Remove(s.address)
+	}
+	s.closeRecords()
+}
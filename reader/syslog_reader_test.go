@@ -0,0 +1,205 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2022, 10, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		given string
+		wants map[string]interface{}
+	}{
+		{
+			name:  "RFC 5424",
+			given: `<165>1 2022-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application \"App\" [1\]"][meta seq="7"] ` + "\ufeff" + "An application event\n",
+			wants: map[string]interface{}{
+				"facility":  "local4",
+				"severity":  "NOTICE",
+				"timestamp": "2022-10-11T22:14:15.003Z",
+				"hostname":  "mymachine.example.com",
+				"appName":   "evntslog",
+				"procId":    "1234",
+				"msgId":     "ID47",
+				"structuredData": map[string]interface{}{
+					"exampleSDID@32473": map[string]interface{}{"iut": "3", "eventSource": `Application "App" [1]`},
+					"meta":              map[string]interface{}{"seq": "7"},
+				},
+				"message": "An application event",
+			},
+		},
+		{
+			name:  "RFC 5424 with nil values",
+			given: `<34>1 - - su - - - 'su root' failed`,
+			wants: map[string]interface{}{
+				"facility": "auth",
+				"severity": "CRITICAL",
+				"appName":  "su",
+				"message":  "'su root' failed",
+			},
+		},
+		{
+			name:  "RFC 3164",
+			given: `<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed for lonvick`,
+			wants: map[string]interface{}{
+				"facility":  "auth",
+				"severity":  "CRITICAL",
+				"timestamp": "2022-10-11T22:14:15Z",
+				"hostname":  "mymachine",
+				"appName":   "su",
+				"procId":    "42",
+				"message":   "'su root' failed for lonvick",
+			},
+		},
+		{
+			name:  "RFC 3164 from last year without hostname",
+			given: `<13>Dec 31 23:59:59 cron: tick`,
+			wants: map[string]interface{}{
+				"facility":  "user",
+				"severity":  "NOTICE",
+				"timestamp": "2021-12-31T23:59:59Z",
+				"appName":   "cron",
+				"message":   "tick",
+			},
+		},
+		{
+			name:  "No priority",
+			given: "just a line",
+			wants: map[string]interface{}{"message": "just a line"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, parseSyslog(test.given, now))
+		})
+	}
+}
+
+func TestSyslogReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		send    func(t *testing.T, addr net.Addr)
+	}{
+		{
+			name:    "UDP",
+			address: "udp://127.0.0.1:0",
+			send: func(t *testing.T, addr net.Addr) {
+				conn, err := net.Dial("udp", addr.String())
+				assert.NoError(t, err)
+				_, err = conn.Write([]byte("<14>1 - host app - - - hello\n"))
+				assert.NoError(t, err)
+				assert.NoError(t, conn.Close())
+			},
+		},
+		{
+			name:    "TCP octet counted",
+			address: "tcp://127.0.0.1:0",
+			send: func(t *testing.T, addr net.Addr) {
+				conn, err := net.Dial("tcp", addr.String())
+				assert.NoError(t, err)
+				msg := "<14>1 - host app - - - hello"
+				_, err = fmt.Fprintf(conn, "%d %s", len(msg), msg)
+				assert.NoError(t, err)
+				assert.NoError(t, conn.Close())
+			},
+		},
+		{
+			name:    "Unix socket",
+			address: "unix://" + filepath.Join(t.TempDir(), "syslog.sock"),
+			send: func(t *testing.T, addr net.Addr) {
+				conn, err := net.Dial("unix", addr.String())
+				assert.NoError(t, err)
+				_, err = conn.Write([]byte("<14>1 - host app - - - hello\n"))
+				assert.NoError(t, err)
+				assert.NoError(t, conn.Close())
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := MakeSyslogReader(test.address, nil)
+			assert.NoError(t, r.StreamInto())
+			defer r.Close()
+
+			test.send(t, r.(*syslogStream).addr())
+			select {
+			case rec := <-r.Records():
+				m := make(map[string]interface{})
+				assert.NoError(t, json.Unmarshal([]byte(rec.Text), &m))
+				assert.Equal(t, "hello", m["message"])
+				assert.Equal(t, "host", m["hostname"])
+				assert.Equal(t, "INFO", m["severity"])
+			case <-time.After(2 * time.Second):
+				t.Fatal("timeout waiting for the syslog message")
+			}
+		})
+	}
+}
+
+func TestSyslogReader_CloseWhileConnecting(t *testing.T) {
+	r := MakeSyslogReader("tcp://127.0.0.1:0", nil)
+	assert.NoError(t, r.StreamInto())
+	addr := r.(*syslogStream).addr().String()
+
+	// Idle connections keep coming while the reader is closed, none of which may be
+	// left open for Close to wait on.
+	stop := make(chan struct{})
+	dialed := make(chan struct{})
+	go func() {
+		defer close(dialed)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if conn, err := net.Dial("tcp", addr); err == nil {
+				defer conn.Close()
+			}
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout closing the reader")
+	}
+	close(stop)
+	<-dialed
+}
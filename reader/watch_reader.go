@@ -0,0 +1,208 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchStream streams every file matching a set of glob patterns, picking up files
+// created after the stream started and dropping the ones that get removed.
+type watchStream struct {
+	*multiFileStream
+	patterns []string
+	watcher  *fsnotify.Watcher
+	streamed map[string]bool
+	watching sync.WaitGroup
+}
+
+// MakeWatchReader builds a reader that streams all files matching the glob patterns
+// (e.g. /var/log/app/*.json) and keeps watching their directories for new matches.
+// Added and removed files are reported through EventNotifier. Among opts, WithOffset
+// is ignored and WithStrChan is superseded by strChan, as for MakeMultiFileReader.
+func MakeWatchReader(patterns []string, strChan chan string, opts ...Option) Reader {
+	c := makeReaderConfig(opts)
+	s := &watchStream{
+		multiFileStream: newMultiFileStream(TypeWatch, nil, strChan),
+		streamed:        make(map[string]bool),
+	}
+	s.withCheckpoints(c)
+	s.follow = c.follow
+	// Cleaned, as are the names of the files fsnotify reports, e.g. ./logs/*.json
+	// is matched against logs/app.json.
+	for _, pattern := range patterns {
+		s.patterns = append(s.patterns, filepath.Clean(pattern))
+	}
+	return s
+}
+
+func (s *watchStream) StreamInto() error {
+	var err error
+	s.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dirs := make(map[string]bool)
+	for _, pattern := range s.patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			_ = s.watcher.Close()
+			return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		patternDirs, err := watchDirs(pattern)
+		if err != nil {
+			_ = s.watcher.Close()
+			return err
+		}
+		for _, dir := range patternDirs {
+			if dirs[dir] {
+				continue
+			}
+			dirs[dir] = true
+			if err := s.watcher.Add(dir); err != nil {
+				_ = s.watcher.Close()
+				return fmt.Errorf("failed to watch %s: %w", dir, err)
+			}
+		}
+	}
+
+	for _, pattern := range s.patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, fileName := range matches {
+			if err := s.add(fileName); err != nil {
+				_ = s.watcher.Close()
+				s.multiFileStream.Close()
+				return err
+			}
+		}
+	}
+
+	s.watching.Add(1)
+	go s.watch()
+	return nil
+}
+
+func (s *watchStream) watch() {
+	defer s.watching.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case ev, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			s.handle(ev)
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			if s.onError != nil {
+				s.onError(err)
+			}
+		}
+	}
+}
+
+func (s *watchStream) handle(ev fsnotify.Event) {
+	switch {
+	case ev.Has(fsnotify.Create):
+		if s.streamed[ev.Name] || !s.matches(ev.Name) {
+			return
+		}
+		if err := s.add(ev.Name); err != nil {
+			if s.onError != nil {
+				s.onError(err)
+			}
+			return
+		}
+		s.notify(Event{Type: EventFileAdded, Source: ev.Name})
+	case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+		if !s.streamed[ev.Name] {
+			return
+		}
+		delete(s.streamed, ev.Name)
+		s.stopFile(ev.Name)
+		s.notify(Event{Type: EventFileRemoved, Source: ev.Name})
+	}
+}
+
+// watchDirs lists the directories to watch for pattern. Globbed directories
+// (e.g. logs/*/app.log) are expanded to the ones existing when the stream starts.
+func watchDirs(pattern string) ([]string, error) {
+	dir := filepath.Dir(pattern)
+	if !hasMeta(dir) {
+		return []string{dir}, nil
+	}
+	matches, _ := filepath.Glob(dir)
+	var dirs []string
+	for _, match := range matches {
+		if fi, err := os.Stat(match); err == nil && fi.IsDir() {
+			dirs = append(dirs, match)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no directory matches %s", dir)
+	}
+	return dirs, nil
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// add starts streaming fileName, skipping anything that isn't a regular file.
+func (s *watchStream) add(fileName string) error {
+	if fi, err := os.Stat(fileName); err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	if err := s.streamFile(fileName); err != nil {
+		return err
+	}
+	s.streamed[fileName] = true
+	return nil
+}
+
+func (s *watchStream) matches(fileName string) bool {
+	for _, pattern := range s.patterns {
+		if ok, _ := filepath.Match(pattern, fileName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *watchStream) Close() {
+	s.stopEmitting()
+	if s.watcher != nil {
+		_ = s.watcher.Close()
+	}
+	s.watching.Wait()
+	s.multiFileStream.Close()
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchReader(t *testing.T) {
+	tempDir := t.TempDir()
+	existing := filepath.Join(tempDir, "existing.json")
+	if err := os.WriteFile(existing, []byte("existing line1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write existing file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "ignored.txt"), []byte("ignored\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignored file: %v", err)
+	}
+
+	var mu sync.Mutex
+	var events []Event
+	reader := MakeWatchReader([]string{filepath.Join(tempDir, "*.json")}, nil)
+	reader.EventNotifier(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+	if err := reader.StreamInto(); err != nil {
+		t.Fatalf("Failed to start streaming: %v", err)
+	}
+	defer reader.Close()
+
+	waitRecord(t, reader, "existing line1", existing)
+
+	created := filepath.Join(tempDir, "created.json")
+	if err := os.WriteFile(created, []byte("created line1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write created file: %v", err)
+	}
+	waitRecord(t, reader, "created line1", created)
+
+	if err := os.Remove(created); err != nil {
+		t.Fatalf("Failed to remove created file: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]Event(nil), events...)
+		mu.Unlock()
+		if len(got) == 2 {
+			if got[0] != (Event{Type: EventFileAdded, Source: created}) ||
+				got[1] != (Event{Type: EventFileRemoved, Source: created}) {
+				t.Errorf("Unexpected events %v", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for events, got %v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitRecord(t *testing.T, reader Reader, text, source string) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	select {
+	case rec := <-reader.Records():
+		if rec.Text != text || rec.Source != source {
+			t.Errorf("Expected %q from %s, got %q from %s", text, source, rec.Text, rec.Source)
+		}
+	case <-timeout:
+		t.Fatalf("Timeout waiting for %q", text)
+	}
+}
+
+func TestWatchReader_GlobbedDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+
+	reader := MakeWatchReader([]string{filepath.Join(tempDir, "*", "app.log")}, nil)
+	if err := reader.StreamInto(); err != nil {
+		t.Fatalf("Failed to start streaming: %v", err)
+	}
+	defer reader.Close()
+
+	created := filepath.Join(tempDir, "b", "app.log")
+	if err := os.WriteFile(created, []byte("created line1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write created file: %v", err)
+	}
+	waitRecord(t, reader, "created line1", created)
+}
+
+func TestWatchReader_RelativePattern(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("logs", 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	reader := MakeWatchReader([]string{"./logs/*.json"}, nil)
+	if err := reader.StreamInto(); err != nil {
+		t.Fatalf("Failed to start streaming: %v", err)
+	}
+	defer reader.Close()
+
+	created := filepath.Join("logs", "created.json")
+	if err := os.WriteFile(created, []byte("created line1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write created file: %v", err)
+	}
+	waitRecord(t, reader, "created line1", created)
+}
+
+func TestWatchReader_NoMatchingDirectory(t *testing.T) {
+	reader := MakeWatchReader([]string{filepath.Join(t.TempDir(), "missing*", "app.log")}, nil)
+	err := reader.StreamInto()
+	if err == nil || !strings.Contains(err.Error(), "no directory matches") {
+		t.Errorf("Expected a no directory matches error, got %v", err)
+	}
+}
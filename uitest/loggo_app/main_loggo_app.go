@@ -43,6 +43,11 @@ func main() {
 	}()
 
 	_ = rd.StreamInto()
-	app := loggo.NewLoggoApp(rd, "")
-	app.Run()
+	app, err := loggo.NewLoggoApp(rd, "")
+	if err != nil {
+		panic(err)
+	}
+	if err := app.Run(); err != nil {
+		panic(err)
+	}
 }